@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"log/slog"
 	"net/http"
 	"os"
@@ -12,12 +13,15 @@ import (
 	"audiobookshelf-asmr-provider/internal/config"
 	"audiobookshelf-asmr-provider/internal/domain/cache"
 	"audiobookshelf-asmr-provider/internal/domain/provider"
+	"audiobookshelf-asmr-provider/internal/domain/provider/all"
+	"audiobookshelf-asmr-provider/internal/domain/provider/breaker"
+	"audiobookshelf-asmr-provider/internal/domain/provider/transport"
 	"audiobookshelf-asmr-provider/internal/handler"
 	"audiobookshelf-asmr-provider/internal/service"
 )
 
 func main() {
-	cfg := config.Load()
+	cfg, warnings := config.Load()
 
 	// Initialize structured logging with level from config
 	var level slog.Level
@@ -34,22 +38,74 @@ func main() {
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
 	slog.SetDefault(logger)
+	for _, w := range warnings {
+		slog.Warn(w.Msg, w.Args...)
+	}
+
+	// A single limiter keeps the load on each upstream host polite across all providers.
+	limiter := transport.NewLimiter(nil,
+		transport.WithRate(cfg.UpstreamRate, cfg.UpstreamBurst),
+		transport.WithMaxConns(cfg.UpstreamMaxConns),
+	)
+	expvar.Publish("upstream", expvar.Func(func() any { return limiter.Stats() }))
 
-	providers := provider.NewAll()
+	providers := provider.NewAllWithOptions(provider.Options{
+		Aggregation: all.Options{
+			FieldPriority:   cfg.MergeFieldPriority,
+			ProviderTimeout: cfg.ProviderTimeout,
+		},
+		Transport: limiter,
+		Breaker: breaker.Options{
+			FailureThreshold: cfg.BreakerFailureThreshold,
+			Cooldown:         cfg.BreakerCooldown,
+		},
+	})
 	slog.Info("Loaded providers", "count", len(providers))
 
-	memCache := cache.NewMemoryCache()
-	svc := service.NewService(memCache, providers...)
+	var metaCache service.Cache
+	switch cfg.CacheBackend {
+	case "file":
+		fileCache, err := cache.NewFileCache(cfg.CacheDir, cache.WithFileMaxEntries(cfg.CacheMaxEntries))
+		if err != nil {
+			slog.Error("Failed to open file cache", "dir", cfg.CacheDir, "error", err)
+			os.Exit(1)
+		}
+		defer fileCache.Close()
+		metaCache = fileCache
+	default:
+		metaCache = cache.NewMemoryCache(
+			cache.WithMaxEntries(cfg.CacheMaxEntries),
+			cache.WithMaxBytes(cfg.CacheMaxBytes),
+		)
+	}
+	slog.Info("Initialized cache", "backend", cfg.CacheBackend)
+
+	svc := service.NewServiceWithOptions(metaCache, service.Options{
+		StaleWhileRevalidate: cfg.CacheStaleWhileRevalidate,
+		StaleIfError:         cfg.CacheStaleIfError,
+		NegativeTTL:          cfg.CacheNegativeTTL,
+	}, providers...)
+	expvar.Publish("parser", expvar.Func(func() any { return svc.ParserStats() }))
 	h := handler.NewHandler(svc)
 	mux := http.NewServeMux()
 
+	mux.HandleFunc("GET /api/providers", h.Providers)
 	mux.HandleFunc("GET /api/search", h.SearchAll)
 	mux.HandleFunc("GET /api/{provider}/search", h.Search)
+	mux.HandleFunc("GET /api/{provider}/works/{id}", h.GetWork)
 
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("OK"))
-	})
+	mux.HandleFunc("/health", h.Health)
+
+	if cfg.AdminToken != "" {
+		admin := handler.AdminAuth(cfg.AdminToken)
+		mux.Handle("GET /admin/cache", admin(http.HandlerFunc(h.CacheStats)))
+		mux.Handle("DELETE /admin/cache", admin(http.HandlerFunc(h.CacheFlush)))
+		mux.Handle("GET /admin/cache/{provider}", admin(http.HandlerFunc(h.CacheLookup)))
+		mux.Handle("DELETE /admin/cache/{provider}", admin(http.HandlerFunc(h.CacheDelete)))
+		mux.Handle("GET /admin/metrics", admin(expvar.Handler()))
+	} else {
+		slog.Info("Admin endpoints disabled; set ADMIN_TOKEN to enable them")
+	}
 
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -2,15 +2,75 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration.
 type Config struct {
 	Port     string
 	LogLevel string
+	// AdminToken protects the /admin endpoints. Admin endpoints are disabled when empty.
+	AdminToken string
+
+	// CacheBackend selects the cache implementation ("memory" or "file").
+	CacheBackend string
+	// CacheDir is the directory used by the file cache backend.
+	CacheDir string
+	// CacheMaxEntries caps the number of entries held by the memory cache.
+	CacheMaxEntries int
+	// CacheMaxBytes is an approximate memory budget for the memory cache (0 = unlimited).
+	CacheMaxBytes int64
+	// CacheStaleWhileRevalidate is how long expired entries are served while being refreshed.
+	CacheStaleWhileRevalidate time.Duration
+	// CacheStaleIfError is how long expired entries may be served when a provider fails.
+	CacheStaleIfError time.Duration
+	// CacheNegativeTTL is how long empty and not-found results are cached.
+	CacheNegativeTTL time.Duration
+
+	// MergeFieldPriority lists, per metadata field, the providers preferred when the
+	// aggregated search merges duplicate records.
+	MergeFieldPriority map[string][]string
+	// ProviderTimeout is how long each provider may take to answer an aggregated search.
+	ProviderTimeout time.Duration
+
+	// UpstreamRate is the sustained number of requests per second sent to each upstream host.
+	UpstreamRate float64
+	// UpstreamBurst is how many requests may be sent to a host at once after a quiet period.
+	UpstreamBurst int
+	// UpstreamMaxConns caps the number of concurrent requests to each upstream host.
+	UpstreamMaxConns int
+
+	// BreakerFailureThreshold is the number of consecutive failures after which a
+	// provider's circuit breaker opens.
+	BreakerFailureThreshold int
+	// BreakerCooldown is how long an open circuit fails fast before probing again.
+	BreakerCooldown time.Duration
+}
+
+// Warning is a problem with the environment that Load worked around, such as an
+// invalid value replaced by its default. Load runs before logging is configured, so
+// it returns its warnings for the caller to log.
+type Warning struct {
+	Msg  string
+	Args []any // slog key-value pairs
+}
+
+// loader reads environment variables and collects the warnings they cause.
+type loader struct {
+	warnings []Warning
+}
+
+func (l *loader) warn(msg string, args ...any) {
+	l.warnings = append(l.warnings, Warning{Msg: msg, Args: args})
 }
 
-func Load() *Config {
+// Load reads the configuration from the environment, along with warnings about
+// values it could not use.
+func Load() (*Config, []Warning) {
+	var l loader
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -21,8 +81,118 @@ func Load() *Config {
 		logLevel = "INFO"
 	}
 
-	return &Config{
-		Port:     port,
-		LogLevel: logLevel,
+	cacheBackend := strings.ToLower(os.Getenv("CACHE_BACKEND"))
+	switch cacheBackend {
+	case "memory", "file":
+	case "":
+		cacheBackend = "memory"
+	default:
+		l.warn("Unknown CACHE_BACKEND, using memory cache", "value", cacheBackend)
+		cacheBackend = "memory"
+	}
+
+	cacheDir := os.Getenv("CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = "data"
+	}
+
+	cfg := &Config{
+		Port:            port,
+		LogLevel:        logLevel,
+		AdminToken:      os.Getenv("ADMIN_TOKEN"),
+		CacheBackend:    cacheBackend,
+		CacheDir:        cacheDir,
+		CacheMaxEntries: int(l.getEnvInt("CACHE_MAX_ENTRIES", 10000)),
+		CacheMaxBytes:   l.getEnvInt("CACHE_MAX_BYTES", 0),
+
+		CacheStaleWhileRevalidate: l.getEnvDuration("CACHE_STALE_WHILE_REVALIDATE", 1*time.Hour),
+		CacheStaleIfError:         l.getEnvDuration("CACHE_STALE_IF_ERROR", 7*24*time.Hour),
+		CacheNegativeTTL:          l.getEnvDuration("CACHE_NEGATIVE_TTL", 15*time.Minute),
+
+		MergeFieldPriority: l.getEnvPriority("MERGE_FIELD_PRIORITY"),
+		ProviderTimeout:    l.getEnvDuration("PROVIDER_TIMEOUT", 12*time.Second),
+
+		UpstreamRate:     l.getEnvFloat("UPSTREAM_RATE_LIMIT", 3),
+		UpstreamBurst:    int(l.getEnvInt("UPSTREAM_BURST", 6)),
+		UpstreamMaxConns: int(l.getEnvInt("UPSTREAM_MAX_CONNS", 4)),
+
+		BreakerFailureThreshold: int(l.getEnvInt("BREAKER_FAILURE_THRESHOLD", 5)),
+		BreakerCooldown:         l.getEnvDuration("BREAKER_COOLDOWN", 30*time.Second),
+	}
+	return cfg, l.warnings
+}
+
+// getEnvInt reads an integer environment variable, falling back to def when unset or invalid.
+func (l *loader) getEnvInt(key string, def int64) int64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		l.warn("Invalid integer environment variable, using default", "key", key, "value", raw, "default", def)
+		return def
+	}
+	return v
+}
+
+// getEnvFloat reads a floating-point environment variable, falling back to def when unset or invalid.
+func (l *loader) getEnvFloat(key string, def float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		l.warn("Invalid number environment variable, using default", "key", key, "value", raw, "default", def)
+		return def
+	}
+	return v
+}
+
+// getEnvDuration reads a duration environment variable (e.g. "90m"), falling back to def when unset or invalid.
+func (l *loader) getEnvDuration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		l.warn("Invalid duration environment variable, using default", "key", key, "value", raw, "default", def.String())
+		return def
+	}
+	return v
+}
+
+// getEnvPriority reads a per-field provider priority such as
+// "description=dlsite;cover=other,dlsite". Malformed entries are skipped, and the
+// last entry of a repeated field wins.
+func (l *loader) getEnvPriority(key string) map[string][]string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return nil
+	}
+	priority := make(map[string][]string)
+	for _, entry := range strings.Split(raw, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		field, list, ok := strings.Cut(entry, "=")
+		field = strings.TrimSpace(field)
+		if !ok || field == "" {
+			l.warn("Invalid priority entry in environment variable, ignoring it", "key", key, "entry", entry)
+			continue
+		}
+		var providers []string
+		for _, id := range strings.Split(list, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				providers = append(providers, id)
+			}
+		}
+		if _, seen := priority[field]; seen {
+			l.warn("Repeated priority field in environment variable, using the last entry", "key", key, "field", field)
+		}
+		priority[field] = providers
 	}
+	return priority
 }
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetEnvPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		want     map[string][]string
+		warnings int
+	}{
+		{"unset", "", nil, 0},
+		{"single field", "description=dlsite", map[string][]string{"description": {"dlsite"}}, 0},
+		{
+			"several fields",
+			"description=dlsite; cover = other , dlsite",
+			map[string][]string{"description": {"dlsite"}, "cover": {"other", "dlsite"}},
+			0,
+		},
+		{"blank entries", ";description=dlsite;; ;", map[string][]string{"description": {"dlsite"}}, 0},
+		{"blank providers", "cover=other,,dlsite,", map[string][]string{"cover": {"other", "dlsite"}}, 0},
+		{"no providers", "cover=", map[string][]string{"cover": nil}, 0},
+		{"missing separator", "description;cover=dlsite", map[string][]string{"cover": {"dlsite"}}, 1},
+		{"missing field", "=dlsite;cover=dlsite", map[string][]string{"cover": {"dlsite"}}, 1},
+		{"repeated field", "cover=dlsite;cover=other", map[string][]string{"cover": {"other"}}, 1},
+		{"only malformed", "description;=dlsite", map[string][]string{}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MERGE_FIELD_PRIORITY", tt.value)
+
+			var l loader
+			got := l.getEnvPriority("MERGE_FIELD_PRIORITY")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getEnvPriority() = %v, want %v", got, tt.want)
+			}
+			if len(l.warnings) != tt.warnings {
+				t.Errorf("expected %d warnings, got %v", tt.warnings, l.warnings)
+			}
+		})
+	}
+}
+
+func TestLoad_Warnings(t *testing.T) {
+	t.Setenv("CACHE_BACKEND", "redis")
+	t.Setenv("CACHE_MAX_ENTRIES", "many")
+	t.Setenv("MERGE_FIELD_PRIORITY", "cover")
+
+	cfg, warnings := Load()
+	if cfg.CacheBackend != "memory" || cfg.CacheMaxEntries != 10000 {
+		t.Errorf("expected defaults for invalid values, got backend %q and %d max entries", cfg.CacheBackend, cfg.CacheMaxEntries)
+	}
+	if len(warnings) != 3 {
+		t.Errorf("expected 3 warnings, got %v", warnings)
+	}
+}
@@ -0,0 +1,455 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+const (
+	fileCacheName = "cache.log"
+
+	// compactMinRecords is the log size below which compaction is never attempted.
+	compactMinRecords = 1000
+)
+
+// logRecord is a single line in the append-only cache log.
+type logRecord struct {
+	Key        string                    `json:"k"`
+	Data       []service.AbsBookMetadata `json:"d,omitempty"`
+	Expiry     int64                     `json:"e"`           // Unix nanoseconds
+	StaleUntil int64                     `json:"s,omitempty"` // Unix nanoseconds
+	Sources    []service.SourceStatus    `json:"src,omitempty"`
+}
+
+func newLogRecord(key string, entry service.CacheEntry) logRecord {
+	return logRecord{
+		Key:        key,
+		Data:       entry.Data,
+		Expiry:     unixNano(entry.Expiry),
+		StaleUntil: unixNano(entry.StaleUntil),
+		Sources:    entry.Sources,
+	}
+}
+
+// unixNano converts t to Unix nanoseconds, mapping the zero time to 0.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func (r logRecord) entry() service.CacheEntry {
+	e := service.CacheEntry{Data: r.Data, Expiry: time.Unix(0, r.Expiry), Sources: r.Sources}
+	if r.StaleUntil != 0 {
+		e.StaleUntil = time.Unix(0, r.StaleUntil)
+	}
+	return e
+}
+
+// FileOption configures a FileCache.
+type FileOption func(*FileCache)
+
+// WithFileMaxEntries sets the maximum number of entries kept in the cache.
+// Values <= 0 keep the default.
+func WithFileMaxEntries(n int) FileOption {
+	return func(c *FileCache) {
+		if n > 0 {
+			c.maxSize = n
+		}
+	}
+}
+
+// FileCache provides a thread-safe cache persisted to an append-only log on disk,
+// so cached metadata survives process restarts.
+// All live entries are also held in memory; the log is only read at startup.
+// When full, the entries closest to expiry are evicted first.
+type FileCache struct {
+	entries         map[string]service.CacheEntry
+	mu              sync.RWMutex
+	maxSize         int
+	path            string
+	file            *os.File
+	writer          *bufio.Writer
+	records         int // number of records in the log, including superseded ones
+	hits            int64
+	misses          int64
+	evictions       int64
+	cleanupInterval time.Duration
+	done            chan struct{}
+}
+
+// NewFileCache opens (or creates) a cache log in dir, replays it, and starts
+// a background goroutine that evicts expired entries and compacts the log.
+func NewFileCache(dir string, opts ...FileOption) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+
+	c := &FileCache{
+		entries:         make(map[string]service.CacheEntry),
+		maxSize:         defaultMaxEntries,
+		path:            filepath.Join(dir, fileCacheName),
+		cleanupInterval: 1 * time.Hour,
+		done:            make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	if err := c.openForAppend(); err != nil {
+		return nil, err
+	}
+	// The limit may have been lowered since the log was written.
+	c.evictOverflow("")
+
+	slog.Info("Loaded file cache", "path", c.path, "entries", len(c.entries), "records", c.records)
+
+	go c.startCleanup()
+	return c, nil
+}
+
+// Get retrieves the cached entry for the given key, if it exists and is still usable.
+func (c *FileCache) Get(key string) (service.CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[key]
+	if found && entry.Usable(time.Now()) {
+		c.hits++
+		return entry, true
+	}
+	c.misses++
+	return service.CacheEntry{}, false
+}
+
+// Peek returns the entry for the given key if it exists and is still usable,
+// without counting a hit or miss.
+func (c *FileCache) Peek(key string) (service.CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[key]
+	if !found || !entry.Usable(time.Now()) {
+		return service.CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Put stores an entry in the cache and appends it to the log.
+// If the cache exceeds its entry limit, the entries closest to expiry are evicted.
+func (c *FileCache) Put(key string, entry service.CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = entry
+
+	if err := c.append(key, entry); err != nil {
+		slog.Error("Failed to write cache record", "path", c.path, "error", err)
+	}
+	c.evictOverflow(key)
+}
+
+// EvictExpired removes all expired entries from the cache and compacts the log
+// when it has grown well beyond the number of live entries.
+func (c *FileCache) EvictExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	initialSize := len(c.entries)
+	now := time.Now()
+	for k, v := range c.entries {
+		if !v.Usable(now) {
+			delete(c.entries, k)
+		}
+	}
+	evictedCount := initialSize - len(c.entries)
+	if evictedCount > 0 {
+		c.evictions += int64(evictedCount)
+		slog.Debug("Evicted expired cache entries", "count", evictedCount)
+	}
+
+	if c.records >= compactMinRecords && c.records > 2*len(c.entries) {
+		if err := c.compact(); err != nil {
+			slog.Error("Failed to compact cache log", "path", c.path, "error", err)
+		}
+	}
+}
+
+// Compact rewrites the log so that it only contains live entries.
+func (c *FileCache) Compact() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.compact()
+}
+
+// Len returns the number of entries in the cache.
+func (c *FileCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Stats returns a snapshot of the cache usage counters.
+// Bytes is the current size of the log on disk.
+func (c *FileCache) Stats() service.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := service.CacheStats{
+		Entries:   len(c.entries),
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+	if info, err := os.Stat(c.path); err == nil {
+		stats.Bytes = info.Size()
+	}
+	return stats
+}
+
+// Delete removes a single key and reports whether it existed.
+// The removal is persisted as a tombstone record.
+func (c *FileCache) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.delete(key)
+}
+
+// DeletePrefix removes all keys starting with prefix and returns how many were removed.
+func (c *FileCache) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) && c.delete(key) {
+			removed++
+		}
+	}
+	return removed
+}
+
+// Flush removes every entry and truncates the log.
+func (c *FileCache) Flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := len(c.entries)
+	c.entries = make(map[string]service.CacheEntry)
+	if err := c.compact(); err != nil {
+		slog.Error("Failed to truncate cache log", "path", c.path, "error", err)
+	}
+	return removed
+}
+
+// Close stops the background cleanup and flushes and closes the log file.
+func (c *FileCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return nil
+	default:
+		close(c.done)
+	}
+
+	if err := c.writer.Flush(); err != nil {
+		_ = c.file.Close()
+		return err
+	}
+	return c.file.Close()
+}
+
+// load replays the log into memory, skipping expired and malformed records.
+func (c *FileCache) load() error {
+	f, err := os.Open(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open cache log: %w", err)
+	}
+	defer f.Close()
+
+	now := time.Now()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn write at the tail of the log is expected after a crash.
+			slog.Warn("Skipping malformed cache record", "path", c.path, "error", err)
+			continue
+		}
+		c.records++
+
+		entry := rec.entry()
+		if !entry.Usable(now) {
+			delete(c.entries, rec.Key)
+			continue
+		}
+		c.entries[rec.Key] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read cache log: %w", err)
+	}
+	return nil
+}
+
+func (c *FileCache) openForAppend() error {
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open cache log: %w", err)
+	}
+	c.file = f
+	c.writer = bufio.NewWriter(f)
+
+	// Terminate a torn trailing record so the next append starts on a fresh line.
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			_ = c.writer.WriteByte('\n')
+		}
+	}
+	return nil
+}
+
+// append writes a record to the log. The caller must hold c.mu.
+func (c *FileCache) append(key string, entry service.CacheEntry) error {
+	line, err := json.Marshal(newLogRecord(key, entry))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := c.writer.Write(line); err != nil {
+		return err
+	}
+	c.records++
+	return c.writer.Flush()
+}
+
+// delete removes key and appends a tombstone, i.e. a record with no expiry that is
+// dropped on replay.
+// The caller must hold c.mu.
+func (c *FileCache) delete(key string) bool {
+	if _, found := c.entries[key]; !found {
+		return false
+	}
+	delete(c.entries, key)
+	if err := c.append(key, service.CacheEntry{}); err != nil {
+		slog.Error("Failed to write cache tombstone", "path", c.path, "error", err)
+	}
+	return true
+}
+
+// evictOverflow evicts the entries closest to expiry until the cache is within its
+// entry limit, sparing keep. The caller must hold c.mu.
+func (c *FileCache) evictOverflow(keep string) {
+	excess := len(c.entries) - c.maxSize
+	if excess <= 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		if k != keep {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return usableUntil(c.entries[a]).Compare(usableUntil(c.entries[b]))
+	})
+	for _, k := range keys[:min(excess, len(keys))] {
+		if c.delete(k) {
+			c.evictions++
+		}
+	}
+}
+
+// usableUntil returns the time after which entry can no longer be served.
+func usableUntil(entry service.CacheEntry) time.Time {
+	if entry.StaleUntil.After(entry.Expiry) {
+		return entry.StaleUntil
+	}
+	return entry.Expiry
+}
+
+// compact writes all live entries to a temporary file and atomically swaps it
+// in place of the current log. The caller must hold c.mu.
+func (c *FileCache) compact() error {
+	tmpPath := c.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for k, v := range c.entries {
+		if err := enc.Encode(newLogRecord(k, v)); err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	_ = c.writer.Flush()
+	_ = c.file.Close()
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		_ = os.Remove(tmpPath)
+		// Keep appending to the old log so no writes are lost.
+		if openErr := c.openForAppend(); openErr != nil {
+			return errors.Join(err, openErr)
+		}
+		return err
+	}
+
+	before := c.records
+	c.records = len(c.entries)
+	slog.Debug("Compacted cache log", "path", c.path, "records_before", before, "records_after", c.records)
+	return c.openForAppend()
+}
+
+// startCleanup periodically removes expired entries until Close is called.
+func (c *FileCache) startCleanup() {
+	ticker := time.NewTicker(c.cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.EvictExpired()
+		case <-c.done:
+			return
+		}
+	}
+}
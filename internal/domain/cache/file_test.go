@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+func newTestFileCache(t *testing.T, dir string) *FileCache {
+	t.Helper()
+	c, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatalf("NewFileCache failed: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestFileCache_GetPut(t *testing.T) {
+	c := newTestFileCache(t, t.TempDir())
+	data := []service.AbsBookMetadata{{Title: "Test", ISBN: "RJ123456"}}
+
+	c.Put("dlsite:RJ123456", testEntry(data, 1*time.Hour))
+
+	got, ok := c.Get("dlsite:RJ123456")
+	if !ok {
+		t.Fatal("expected item to be in cache")
+	}
+	if len(got.Data) != 1 || got.Data[0].Title != "Test" {
+		t.Errorf("unexpected data: %+v", got)
+	}
+}
+
+func TestFileCache_PersistsAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	c1, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatalf("NewFileCache failed: %v", err)
+	}
+	c1.Put("a", testEntry([]service.AbsBookMetadata{{Title: "Old"}}, 1*time.Hour))
+	c1.Put("a", testEntry([]service.AbsBookMetadata{{Title: "New"}}, 1*time.Hour))
+	c1.Put("b", testEntry([]service.AbsBookMetadata{{Title: "Expired"}}, 1*time.Millisecond))
+	if err := c1.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	c2 := newTestFileCache(t, dir)
+
+	got, ok := c2.Get("a")
+	if !ok {
+		t.Fatal("expected entry to survive restart")
+	}
+	if got.Data[0].Title != "New" {
+		t.Errorf("expected latest value 'New', got %q", got.Data[0].Title)
+	}
+	if _, ok := c2.Get("b"); ok {
+		t.Error("expected expired entry to be dropped on load")
+	}
+	if c2.Len() != 1 {
+		t.Errorf("expected 1 live entry, got %d", c2.Len())
+	}
+}
+
+func TestFileCache_Expiration(t *testing.T) {
+	c := newTestFileCache(t, t.TempDir())
+	c.Put("key", testEntry([]service.AbsBookMetadata{{Title: "Expired"}}, 1*time.Millisecond))
+	time.Sleep(10 * time.Millisecond)
+
+	if _, ok := c.Get("key"); ok {
+		t.Error("expected item to be expired")
+	}
+
+	c.EvictExpired()
+	if c.Len() != 0 {
+		t.Errorf("expected 0 items, got %d", c.Len())
+	}
+}
+
+func TestFileCache_Compact(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestFileCache(t, dir)
+
+	for i := 0; i < 50; i++ {
+		c.Put("same", testEntry([]service.AbsBookMetadata{{Title: "v"}}, 1*time.Hour))
+	}
+	c.Put("other", testEntry([]service.AbsBookMetadata{{Title: "o"}}, 1*time.Hour))
+
+	if err := c.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, fileCacheName))
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	if lines := bytes.Count(raw, []byte("\n")); lines != 2 {
+		t.Errorf("expected 2 records after compaction, got %d", lines)
+	}
+
+	// Writes after compaction must still be persisted.
+	c.Put("after", testEntry([]service.AbsBookMetadata{{Title: "a"}}, 1*time.Hour))
+	_ = c.Close()
+
+	reopened := newTestFileCache(t, dir)
+	if reopened.Len() != 3 {
+		t.Errorf("expected 3 entries after reopen, got %d", reopened.Len())
+	}
+}
+
+func TestFileCache_SkipsMalformedRecords(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, fileCacheName)
+	expiry := time.Now().Add(time.Hour).UnixNano()
+	content := `{"k":"good","d":[{"title":"Good","author":""}],"e":` + strconv.FormatInt(expiry, 10) + "}\n" + `{"k":"torn","d":[{"ti`
+	if err := os.WriteFile(logPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to seed log: %v", err)
+	}
+
+	c := newTestFileCache(t, dir)
+	if _, ok := c.Get("good"); !ok {
+		t.Error("expected valid record to be loaded")
+	}
+	if _, ok := c.Get("torn"); ok {
+		t.Error("expected torn record to be skipped")
+	}
+
+	// Appending after a torn tail must not corrupt the new record.
+	c.Put("next", testEntry([]service.AbsBookMetadata{{Title: "Next"}}, 1*time.Hour))
+	_ = c.Close()
+
+	reopened := newTestFileCache(t, dir)
+	if _, ok := reopened.Get("next"); !ok {
+		t.Error("expected record written after torn tail to be readable")
+	}
+}
+
+func TestFileCache_PersistsStaleWindow(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	c1, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatalf("NewFileCache failed: %v", err)
+	}
+	c1.Put("stale", service.CacheEntry{
+		Data:       []service.AbsBookMetadata{{Title: "Stale"}},
+		Expiry:     now.Add(-1 * time.Minute),
+		StaleUntil: now.Add(1 * time.Hour),
+	})
+	_ = c1.Close()
+
+	c2 := newTestFileCache(t, dir)
+	got, ok := c2.Get("stale")
+	if !ok {
+		t.Fatal("expected stale entry to survive restart")
+	}
+	if got.Fresh(now) || !got.StaleUntil.After(now) {
+		t.Errorf("unexpected freshness window: expiry=%v staleUntil=%v", got.Expiry, got.StaleUntil)
+	}
+}
+
+func TestFileCache_DeleteAndPurgePersist(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestFileCache(t, dir)
+	c.Put("dlsite:a", testEntry([]service.AbsBookMetadata{{Title: "A"}}, 1*time.Hour))
+	c.Put("dlsite:b", testEntry([]service.AbsBookMetadata{{Title: "B"}}, 1*time.Hour))
+	c.Put("all:a", testEntry([]service.AbsBookMetadata{{Title: "All"}}, 1*time.Hour))
+	c.Put("void:a", testEntry([]service.AbsBookMetadata{{Title: "Void"}}, 1*time.Hour))
+
+	if !c.Delete("void:a") {
+		t.Error("expected Delete to report an existing key")
+	}
+	if removed := c.DeletePrefix("dlsite:"); removed != 2 {
+		t.Errorf("expected 2 entries purged, got %d", removed)
+	}
+	_ = c.Close()
+
+	reopened := newTestFileCache(t, dir)
+	if reopened.Len() != 1 {
+		t.Fatalf("expected deletions to survive restart, got %d entries", reopened.Len())
+	}
+	if _, ok := reopened.Get("all:a"); !ok {
+		t.Error("expected untouched entry to survive restart")
+	}
+
+	if removed := reopened.Flush(); removed != 1 {
+		t.Errorf("expected 1 entry flushed, got %d", removed)
+	}
+	if stats := reopened.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("expected empty cache and log after flush, got %+v", stats)
+	}
+}
+
+func TestFileCache_MaxEntries(t *testing.T) {
+	dir := t.TempDir()
+	c1, err := NewFileCache(dir, WithFileMaxEntries(2))
+	if err != nil {
+		t.Fatalf("NewFileCache failed: %v", err)
+	}
+	data := []service.AbsBookMetadata{{Title: "Test"}}
+	c1.Put("soon", testEntry(data, 1*time.Hour))
+	c1.Put("late", testEntry(data, 3*time.Hour))
+	c1.Put("new", testEntry(data, 30*time.Minute))
+
+	if c1.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", c1.Len())
+	}
+	if _, ok := c1.Peek("soon"); ok {
+		t.Error("expected the entry closest to expiry to be evicted")
+	}
+	if _, ok := c1.Peek("new"); !ok {
+		t.Error("expected the entry just written to be kept")
+	}
+	if got := c1.Stats().Evictions; got != 1 {
+		t.Errorf("expected 1 eviction, got %d", got)
+	}
+	if err := c1.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// The eviction is persisted, and a lower limit applies to the replayed log.
+	c2, err := NewFileCache(dir, WithFileMaxEntries(1))
+	if err != nil {
+		t.Fatalf("NewFileCache failed: %v", err)
+	}
+	t.Cleanup(func() { _ = c2.Close() })
+	if c2.Len() != 1 {
+		t.Fatalf("expected 1 entry after restart, got %d", c2.Len())
+	}
+	if _, ok := c2.Peek("late"); !ok {
+		t.Error("expected the entry furthest from expiry to survive the restart")
+	}
+}
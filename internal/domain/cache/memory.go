@@ -1,68 +1,140 @@
 package cache
 
 import (
+	"container/list"
+	"encoding/json"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
 	"audiobookshelf-asmr-provider/internal/service"
 )
 
-// cacheEntry holds the cached metadata and its expiration time.
-type cacheEntry struct {
-	data   []service.AbsBookMetadata
-	expiry time.Time
+const defaultMaxEntries = 10000
+
+// lruItem is the value stored in each element of the LRU list.
+type lruItem struct {
+	key   string
+	entry service.CacheEntry
+	size  int64
+}
+
+// Option configures a MemoryCache.
+type Option func(*MemoryCache)
+
+// WithMaxEntries sets the maximum number of entries kept in the cache.
+// Values <= 0 keep the default.
+func WithMaxEntries(n int) Option {
+	return func(c *MemoryCache) {
+		if n > 0 {
+			c.maxSize = n
+		}
+	}
+}
+
+// WithMaxBytes sets an approximate memory budget for cached data.
+// The size of an entry is estimated from its JSON encoding. Values <= 0 disable the limit.
+func WithMaxBytes(n int64) Option {
+	return func(c *MemoryCache) {
+		if n > 0 {
+			c.maxBytes = n
+		}
+	}
 }
 
 // MemoryCache provides thread-safe in-memory caching for metadata results.
+// When full, the least recently used entries are evicted first.
 type MemoryCache struct {
-	entries         map[string]cacheEntry
-	mu              sync.RWMutex
+	entries         map[string]*list.Element
+	order           *list.List // front = most recently used
+	mu              sync.Mutex
 	maxSize         int
+	maxBytes        int64
+	bytes           int64
+	hits            int64
+	misses          int64
+	evictions       int64
 	cleanupInterval time.Duration
 }
 
 // NewMemoryCache creates a new cache and starts a background goroutine to evict expired entries.
-func NewMemoryCache() *MemoryCache {
+func NewMemoryCache(opts ...Option) *MemoryCache {
 	c := &MemoryCache{
-		entries:         make(map[string]cacheEntry),
-		maxSize:         10000,
+		entries:         make(map[string]*list.Element),
+		order:           list.New(),
+		maxSize:         defaultMaxEntries,
 		cleanupInterval: 1 * time.Hour,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
 	go c.startCleanup()
 	return c
 }
 
-// Get retrieves cached data for the given key, if it exists and has not expired.
-func (c *MemoryCache) Get(key string) ([]service.AbsBookMetadata, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// Get retrieves the cached entry for the given key, if it exists and is still usable.
+// A hit marks the entry as most recently used.
+func (c *MemoryCache) Get(key string) (service.CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, found := c.entries[key]
+	if !found {
+		c.misses++
+		return service.CacheEntry{}, false
+	}
+
+	item := elem.Value.(*lruItem)
+	if !item.entry.Usable(time.Now()) {
+		c.misses++
+		return service.CacheEntry{}, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return item.entry, true
+}
+
+// Peek returns the entry for the given key if it exists and is still usable,
+// without counting a hit or miss or refreshing its position in the LRU order.
+func (c *MemoryCache) Peek(key string) (service.CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	entry, found := c.entries[key]
-	if found && time.Now().Before(entry.expiry) {
-		return entry.data, true
+	elem, found := c.entries[key]
+	if !found || !elem.Value.(*lruItem).entry.Usable(time.Now()) {
+		return service.CacheEntry{}, false
 	}
-	return nil, false
+	return elem.Value.(*lruItem).entry, true
 }
 
-// Put stores data in the cache with the given TTL.
-// If the cache exceeds maxSize, one entry is evicted.
-func (c *MemoryCache) Put(key string, data []service.AbsBookMetadata, ttl time.Duration) {
+// Put stores an entry in the cache until it is no longer usable.
+// If the cache exceeds its entry or byte limit, least recently used entries are evicted.
+func (c *MemoryCache) Put(key string, entry service.CacheEntry) {
+	var size int64
+	if c.maxBytes > 0 {
+		size = estimateSize(key, entry.Data)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.entries[key] = cacheEntry{
-		data:   data,
-		expiry: time.Now().Add(ttl),
+	if elem, found := c.entries[key]; found {
+		item := elem.Value.(*lruItem)
+		c.bytes += size - item.size
+		item.entry = entry
+		item.size = size
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.order.PushFront(&lruItem{key: key, entry: entry, size: size})
+		c.bytes += size
 	}
 
-	// Size limit protection
-	if len(c.entries) > c.maxSize {
-		// Evict a random entry (map iteration order is random)
-		for k := range c.entries {
-			delete(c.entries, k)
-			break
-		}
+	// Size limit protection. The entry just written is never evicted by its own Put.
+	for c.order.Len() > 1 && (len(c.entries) > c.maxSize || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.removeElement(c.order.Back())
+		c.evictions++
 	}
 }
 
@@ -73,24 +145,86 @@ func (c *MemoryCache) EvictExpired() {
 
 	initialSize := len(c.entries)
 	now := time.Now()
-	for k, v := range c.entries {
-		if now.After(v.expiry) {
-			delete(c.entries, k)
+	for _, elem := range c.entries {
+		if !elem.Value.(*lruItem).entry.Usable(now) {
+			c.removeElement(elem)
 		}
 	}
 	evictedCount := initialSize - len(c.entries)
 	if evictedCount > 0 {
+		c.evictions += int64(evictedCount)
 		slog.Debug("Evicted expired cache entries", "count", evictedCount)
 	}
 }
 
 // Len returns the number of entries in the cache.
 func (c *MemoryCache) Len() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return len(c.entries)
 }
 
+// Stats returns a snapshot of the cache usage counters.
+// Bytes is only tracked when a byte budget is configured.
+func (c *MemoryCache) Stats() service.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return service.CacheStats{
+		Entries:   len(c.entries),
+		Bytes:     c.bytes,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+// Delete removes a single key and reports whether it existed.
+func (c *MemoryCache) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, found := c.entries[key]
+	if found {
+		c.removeElement(elem)
+	}
+	return found
+}
+
+// DeletePrefix removes all keys starting with prefix and returns how many were removed.
+func (c *MemoryCache) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(elem)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Flush removes every entry and returns how many were removed.
+func (c *MemoryCache) Flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := len(c.entries)
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.bytes = 0
+	return removed
+}
+
+// removeElement unlinks an entry from both the map and the LRU list. The caller must hold c.mu.
+func (c *MemoryCache) removeElement(elem *list.Element) {
+	item := elem.Value.(*lruItem)
+	c.order.Remove(elem)
+	delete(c.entries, item.key)
+	c.bytes -= item.size
+}
+
 // startCleanup periodically removes expired entries.
 func (c *MemoryCache) startCleanup() {
 	ticker := time.NewTicker(c.cleanupInterval)
@@ -99,3 +233,12 @@ func (c *MemoryCache) startCleanup() {
 		c.EvictExpired()
 	}
 }
+
+// estimateSize approximates the memory held by an entry using its JSON encoding.
+func estimateSize(key string, data []service.AbsBookMetadata) int64 {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return int64(len(key))
+	}
+	return int64(len(key) + len(encoded))
+}
@@ -7,19 +7,25 @@ import (
 	"audiobookshelf-asmr-provider/internal/service"
 )
 
+// testEntry builds an entry that is fresh for ttl and has no stale window.
+func testEntry(data []service.AbsBookMetadata, ttl time.Duration) service.CacheEntry {
+	expiry := time.Now().Add(ttl)
+	return service.CacheEntry{Data: data, Expiry: expiry, StaleUntil: expiry}
+}
+
 func TestMemoryCache_GetPut(t *testing.T) {
 	c := NewMemoryCache()
 	key := "test_key"
 	data := []service.AbsBookMetadata{{Title: "Test"}}
 	ttl := 1 * time.Hour
 
-	c.Put(key, data, ttl)
+	c.Put(key, testEntry(data, ttl))
 
 	got, ok := c.Get(key)
 	if !ok {
 		t.Fatal("expected item to be in cache")
 	}
-	if len(got) != 1 || got[0].Title != "Test" {
+	if len(got.Data) != 1 || got.Data[0].Title != "Test" {
 		t.Errorf("unexpected data: %+v", got)
 	}
 }
@@ -30,7 +36,7 @@ func TestMemoryCache_Expiration(t *testing.T) {
 	data := []service.AbsBookMetadata{{Title: "Expired"}}
 	ttl := 1 * time.Millisecond
 
-	c.Put(key, data, ttl)
+	c.Put(key, testEntry(data, ttl))
 	time.Sleep(10 * time.Millisecond)
 
 	_, ok := c.Get(key)
@@ -45,7 +51,7 @@ func TestMemoryCache_EvictExpired(t *testing.T) {
 	data := []service.AbsBookMetadata{{Title: "Data"}}
 	ttl := 1 * time.Millisecond
 
-	c.Put(key, data, ttl)
+	c.Put(key, testEntry(data, ttl))
 	time.Sleep(10 * time.Millisecond)
 
 	c.EvictExpired()
@@ -53,13 +59,162 @@ func TestMemoryCache_EvictExpired(t *testing.T) {
 	if c.Len() != 0 {
 		t.Errorf("expected 0 items, got %d", c.Len())
 	}
+	if got := c.Stats().Evictions; got != 1 {
+		t.Errorf("expected the expired entry to count as an eviction, got %d", got)
+	}
+}
+
+func TestMemoryCache_Peek(t *testing.T) {
+	c := NewMemoryCache(WithMaxEntries(2))
+	c.Put("a", testEntry([]service.AbsBookMetadata{{Title: "A"}}, time.Hour))
+	c.Put("b", testEntry([]service.AbsBookMetadata{{Title: "B"}}, time.Hour))
+
+	if entry, ok := c.Peek("a"); !ok || entry.Data[0].Title != "A" {
+		t.Fatalf("expected to peek at a, got %+v, %v", entry, ok)
+	}
+	if _, ok := c.Peek("missing"); ok {
+		t.Error("expected no entry for a missing key")
+	}
+	if stats := c.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("expected peeking not to count hits or misses, got %+v", stats)
+	}
+
+	// Peeking did not make a the most recently used entry.
+	c.Put("c", testEntry([]service.AbsBookMetadata{}, time.Hour))
+	if _, ok := c.Peek("a"); ok {
+		t.Error("expected a to be evicted as the least recently used entry")
+	}
 }
 
 func TestMemoryCache_Len(t *testing.T) {
 	c := NewMemoryCache()
-	c.Put("a", []service.AbsBookMetadata{}, 1*time.Hour)
-	c.Put("b", []service.AbsBookMetadata{}, 1*time.Hour)
+	c.Put("a", testEntry([]service.AbsBookMetadata{}, 1*time.Hour))
+	c.Put("b", testEntry([]service.AbsBookMetadata{}, 1*time.Hour))
 	if c.Len() != 2 {
 		t.Errorf("expected Len 2, got %d", c.Len())
 	}
 }
+
+func TestMemoryCache_LRUEviction(t *testing.T) {
+	c := NewMemoryCache(WithMaxEntries(2))
+	c.Put("a", testEntry([]service.AbsBookMetadata{{Title: "A"}}, 1*time.Hour))
+	c.Put("b", testEntry([]service.AbsBookMetadata{{Title: "B"}}, 1*time.Hour))
+
+	// Touch "a" so that "b" becomes the least recently used entry.
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected 'a' to be cached")
+	}
+	c.Put("c", testEntry([]service.AbsBookMetadata{{Title: "C"}}, 1*time.Hour))
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected least recently used entry 'b' to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected recently used entry 'a' to be kept")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Error("expected newest entry 'c' to be kept")
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected Len 2, got %d", c.Len())
+	}
+}
+
+func TestMemoryCache_MaxBytes(t *testing.T) {
+	entrySize := estimateSize("a", []service.AbsBookMetadata{{Title: "A"}})
+	c := NewMemoryCache(WithMaxBytes(2 * entrySize))
+
+	c.Put("a", testEntry([]service.AbsBookMetadata{{Title: "A"}}, 1*time.Hour))
+	c.Put("b", testEntry([]service.AbsBookMetadata{{Title: "B"}}, 1*time.Hour))
+	c.Put("c", testEntry([]service.AbsBookMetadata{{Title: "C"}}, 1*time.Hour))
+
+	stats := c.Stats()
+	if stats.Entries != 2 {
+		t.Errorf("expected 2 entries within byte budget, got %d", stats.Entries)
+	}
+	if stats.Bytes > 2*entrySize {
+		t.Errorf("expected bytes <= %d, got %d", 2*entrySize, stats.Bytes)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected oldest entry 'a' to be evicted")
+	}
+}
+
+func TestMemoryCache_OverwriteUpdatesBytes(t *testing.T) {
+	c := NewMemoryCache(WithMaxBytes(1 << 20))
+	c.Put("a", testEntry([]service.AbsBookMetadata{{Title: "short"}}, 1*time.Hour))
+	c.Put("a", testEntry([]service.AbsBookMetadata{{Title: "a considerably longer title"}}, 1*time.Hour))
+
+	want := estimateSize("a", []service.AbsBookMetadata{{Title: "a considerably longer title"}})
+	if got := c.Stats().Bytes; got != want {
+		t.Errorf("expected bytes %d after overwrite, got %d", want, got)
+	}
+}
+
+func TestMemoryCache_Stats(t *testing.T) {
+	c := NewMemoryCache(WithMaxEntries(1))
+	c.Put("a", testEntry([]service.AbsBookMetadata{}, 1*time.Hour))
+	c.Get("a")       // hit
+	c.Get("missing") // miss
+	c.Put("b", testEntry([]service.AbsBookMetadata{}, 1*time.Hour))
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Entries != 1 {
+		t.Errorf("expected 1 entry, got %d", stats.Entries)
+	}
+}
+
+func TestMemoryCache_StaleWindow(t *testing.T) {
+	c := NewMemoryCache()
+	now := time.Now()
+	c.Put("stale", service.CacheEntry{
+		Data:       []service.AbsBookMetadata{{Title: "Stale"}},
+		Expiry:     now.Add(-1 * time.Minute),
+		StaleUntil: now.Add(1 * time.Hour),
+	})
+
+	got, ok := c.Get("stale")
+	if !ok {
+		t.Fatal("expected entry within its stale window to be returned")
+	}
+	if got.Fresh(now) {
+		t.Error("expected entry to be reported as not fresh")
+	}
+
+	c.EvictExpired()
+	if c.Len() != 1 {
+		t.Errorf("expected stale entry to be retained, got Len %d", c.Len())
+	}
+}
+
+func TestMemoryCache_DeleteAndPurge(t *testing.T) {
+	c := NewMemoryCache()
+	c.Put("dlsite:a", testEntry([]service.AbsBookMetadata{}, 1*time.Hour))
+	c.Put("dlsite:b", testEntry([]service.AbsBookMetadata{}, 1*time.Hour))
+	c.Put("all:a", testEntry([]service.AbsBookMetadata{}, 1*time.Hour))
+
+	if !c.Delete("dlsite:a") {
+		t.Error("expected Delete to report an existing key")
+	}
+	if c.Delete("dlsite:a") {
+		t.Error("expected Delete to report a missing key")
+	}
+
+	c.Put("dlsite:c", testEntry([]service.AbsBookMetadata{}, 1*time.Hour))
+	if removed := c.DeletePrefix("dlsite:"); removed != 2 {
+		t.Errorf("expected 2 entries purged, got %d", removed)
+	}
+	if _, ok := c.Get("all:a"); !ok {
+		t.Error("expected entries of other providers to be kept")
+	}
+
+	if removed := c.Flush(); removed != 1 {
+		t.Errorf("expected 1 entry flushed, got %d", removed)
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected empty cache after flush, got %d", c.Len())
+	}
+}
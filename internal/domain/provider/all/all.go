@@ -2,8 +2,9 @@ package all
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
-	"sync"
 	"time"
 
 	"audiobookshelf-asmr-provider/internal/service"
@@ -12,12 +13,44 @@ import (
 // Provider implements the service.Provider interface by aggregating results from multiple providers.
 type Provider struct {
 	providers []service.Provider
+	opts      Options
+}
+
+// Options tunes how the aggregation provider merges results.
+type Options struct {
+	// FieldPriority lists, per field (e.g. "description" or "cover"), the IDs of the
+	// providers whose value is preferred when duplicate records are merged. Providers
+	// not listed follow in registration order.
+	FieldPriority map[string][]string
+	// ProviderTimeout is how long each sub-provider may take to answer a search.
+	// Zero selects the default.
+	ProviderTimeout time.Duration
+}
+
+const (
+	// defaultProviderTimeout keeps aggregated searches below the server's write timeout.
+	defaultProviderTimeout = 12 * time.Second
+	// timeoutGrace is how long to wait past the timeout for providers to give up.
+	timeoutGrace = 100 * time.Millisecond
+)
+
+func (o Options) providerTimeout() time.Duration {
+	if o.ProviderTimeout <= 0 {
+		return defaultProviderTimeout
+	}
+	return o.ProviderTimeout
 }
 
 // NewProvider creates a new aggregation provider with the given sub-providers.
 func NewProvider(providers ...service.Provider) *Provider {
+	return NewProviderWithOptions(Options{}, providers...)
+}
+
+// NewProviderWithOptions creates a new aggregation provider with custom merge options.
+func NewProviderWithOptions(opts Options, providers ...service.Provider) *Provider {
 	return &Provider{
 		providers: providers,
+		opts:      opts,
 	}
 }
 
@@ -26,35 +59,182 @@ func (p *Provider) ID() string {
 	return "all"
 }
 
+// Describe reports the union of the sub-providers' query kinds, filters and adult content support.
+func (p *Provider) Describe() service.ProviderInfo {
+	info := service.ProviderInfo{Name: "All Providers"}
+	seen := make(map[service.QueryKind]bool)
+	seenFilters := make(map[service.Filter]bool)
+
+	for _, provider := range p.providers {
+		d, ok := provider.(service.Describer)
+		if !ok {
+			continue
+		}
+		sub := d.Describe()
+		info.AdultContent = info.AdultContent || sub.AdultContent
+		for _, kind := range sub.QueryKinds {
+			if !seen[kind] {
+				seen[kind] = true
+				info.QueryKinds = append(info.QueryKinds, kind)
+			}
+		}
+		for _, filter := range sub.Filters {
+			if !seenFilters[filter] {
+				seenFilters[filter] = true
+				info.Filters = append(info.Filters, filter)
+			}
+		}
+	}
+	return info
+}
+
 // Search queries all registered providers in parallel and aggregates their results.
-func (p *Provider) Search(ctx context.Context, query string) ([]service.AbsBookMetadata, error) {
+// See SearchWithSources.
+func (p *Provider) Search(ctx context.Context, query service.Query) ([]service.AbsBookMetadata, error) {
+	matches, _, err := p.SearchWithSources(ctx, query)
+	return matches, err
+}
+
+// SearchWithSources queries all registered providers in parallel and aggregates the
+// results of those that answer within the provider timeout. Records of the same work
+// are merged into one, and the results are ranked by relevance to the query.
+// Providers that do not support the query's filters are skipped, since their results
+// would not honour them. The returned statuses, one per provider in registration
+// order, report which providers failed, timed out or were skipped.
+// An error is only returned if every provider that was asked failed; it joins the
+// providers' errors, so that e.g. service.ErrCircuitOpen can still be detected.
+func (p *Provider) SearchWithSources(ctx context.Context, query service.Query) ([]service.AbsBookMetadata, []service.SourceStatus, error) {
+	type outcome struct {
+		i       int
+		records []sourced
+		err     error
+	}
+
 	var (
-		wg         sync.WaitGroup
-		mu         sync.Mutex
-		allMatches []service.AbsBookMetadata
+		// Results are kept per provider so that they can be concatenated in
+		// registration order, whatever order the providers finish in.
+		perProvider = make([][]sourced, len(p.providers))
+		sources     = make([]service.SourceStatus, len(p.providers))
+		// Buffered so that providers finishing after the deadline never block.
+		outcomes = make(chan outcome, len(p.providers))
+		pending  = make(map[int]bool)
+		errs     = make([]error, len(p.providers))
 	)
 
 	slog.Info("Starting aggregated search in AllProvider", "query", query, "providers_count", len(p.providers))
 
-	for _, provider := range p.providers {
-		wg.Add(1)
-		go func(pr service.Provider) {
-			defer wg.Done()
+	timeout := p.opts.providerTimeout()
+	for i, provider := range p.providers {
+		sources[i] = service.SourceStatus{Provider: provider.ID(), State: service.SourceSkipped}
+		if unsupported := service.UnsupportedFilters(provider, query.Filters); len(unsupported) > 0 {
+			slog.Debug("Skipping provider without filter support in AllProvider", "provider", provider.ID(), "filters", unsupported)
+			continue
+		}
+
+		pending[i] = true
+		go func(i int, pr service.Provider) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
 			matches, err := pr.Search(ctx, query)
-			if err != nil {
-				slog.Error("Provider search failed in AllProvider", "provider", pr.ID(), "error", err)
-				return
+			records := make([]sourced, len(matches))
+			for j, m := range matches {
+				records[j] = sourced{meta: m, provider: pr.ID()}
+			}
+			outcomes <- outcome{i: i, records: records, err: err}
+		}(i, provider)
+	}
+
+	// Providers are expected to give up when their context expires, but one that does
+	// not must not hold up the others: stop waiting shortly after the deadline.
+	deadline := time.NewTimer(timeout + timeoutGrace)
+	defer deadline.Stop()
+
+	asked := len(pending)
+	for len(pending) > 0 {
+		select {
+		case o := <-outcomes:
+			delete(pending, o.i)
+			sources[o.i] = p.sourceStatus(p.providers[o.i], o.records, o.err)
+			perProvider[o.i] = o.records
+			errs[o.i] = o.err
+		case <-deadline.C:
+			for i := range pending {
+				slog.Warn("Provider timed out in AllProvider", "provider", p.providers[i].ID(), "timeout", timeout)
+				sources[i].State = service.SourceTimeout
+				errs[i] = fmt.Errorf("%s: %w", p.providers[i].ID(), context.DeadlineExceeded)
 			}
+			clear(pending)
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	var (
+		failed     []error
+		allRecords []sourced
+	)
+	for i, records := range perProvider {
+		if sources[i].Partial() {
+			failed = append(failed, errs[i])
+			continue
+		}
+		allRecords = append(allRecords, records...)
+	}
+	if asked > 0 && len(failed) == asked {
+		return nil, sources, fmt.Errorf("all %d providers failed: %w", asked, errors.Join(failed...))
+	}
+	return p.rank(p.dedupe(allRecords), query), sources, nil
+}
 
-			mu.Lock()
-			allMatches = append(allMatches, matches...)
-			mu.Unlock()
-		}(provider)
+// sourceStatus reports the outcome of one provider's search.
+func (p *Provider) sourceStatus(pr service.Provider, records []sourced, err error) service.SourceStatus {
+	status := service.SourceStatus{Provider: pr.ID(), State: service.SourceOK, Count: len(records)}
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrNotFound):
+		slog.Debug("Provider reported not found in AllProvider", "provider", pr.ID(), "error", err)
+		status.Count = 0
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.Warn("Provider timed out in AllProvider", "provider", pr.ID(), "error", err)
+		status = service.SourceStatus{Provider: pr.ID(), State: service.SourceTimeout, Error: err.Error()}
+	default:
+		slog.Error("Provider search failed in AllProvider", "provider", pr.ID(), "error", err)
+		status = service.SourceStatus{Provider: pr.ID(), State: service.SourceFailed, Error: err.Error()}
 	}
+	return status
+}
+
+// GetWork asks each sub-provider that supports work lookup, in registration order,
+// and returns the first work found.
+func (p *Provider) GetWork(ctx context.Context, id string) (*service.WorkDetail, error) {
+	var lastErr error
+	supported := false
 
-	wg.Wait()
+	for _, provider := range p.providers {
+		fetcher, ok := provider.(service.WorkFetcher)
+		if !ok {
+			continue
+		}
+		supported = true
+
+		work, err := fetcher.GetWork(ctx, id)
+		if err == nil {
+			return work, nil
+		}
+		if !errors.Is(err, service.ErrNotFound) {
+			slog.Error("Provider work lookup failed in AllProvider", "provider", provider.ID(), "id", id, "error", err)
+			lastErr = err
+		}
+	}
 
-	return allMatches, nil
+	if !supported {
+		return nil, service.ErrWorkLookupUnsupported
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("work %q: %w", id, service.ErrNotFound)
 }
 
 // CacheTTL returns the duration for which results should be cached.
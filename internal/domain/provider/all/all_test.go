@@ -3,9 +3,14 @@ package all
 import (
 	"context"
 	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"audiobookshelf-asmr-provider/internal/domain/provider/textmatch"
 	"audiobookshelf-asmr-provider/internal/service"
 )
 
@@ -17,10 +22,23 @@ type mockProvider struct {
 
 func (m *mockProvider) ID() string              { return m.id }
 func (m *mockProvider) CacheTTL() time.Duration { return 1 * time.Hour }
-func (m *mockProvider) Search(_ context.Context, _ string) ([]service.AbsBookMetadata, error) {
+func (m *mockProvider) Search(_ context.Context, _ service.Query) ([]service.AbsBookMetadata, error) {
 	return m.results, m.err
 }
 
+// mockCodeRegex matches the product IDs of codeProvider.
+var mockCodeRegex = regexp.MustCompile(`(?i)\bRJ\d+\b`)
+
+// codeProvider is a mockProvider whose works have product IDs such as RJ123456.
+type codeProvider struct {
+	mockProvider
+}
+
+func (c *codeProvider) FindProductID(s string) (string, bool) {
+	id := mockCodeRegex.FindString(textmatch.ToHalfWidth(s))
+	return strings.ToUpper(id), id != ""
+}
+
 func TestAllProvider_Search(t *testing.T) {
 	p1 := &mockProvider{
 		id:      "p1",
@@ -37,7 +55,7 @@ func TestAllProvider_Search(t *testing.T) {
 
 	ap := NewProvider(p1, p2, pFail)
 
-	results, err := ap.Search(context.Background(), "test")
+	results, err := ap.Search(context.Background(), service.Query{Text: "test"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -76,3 +94,260 @@ func TestAllProvider_CacheTTL(t *testing.T) {
 		t.Errorf("expected 1h TTL, got %v", ap.CacheTTL())
 	}
 }
+
+type mockWorkProvider struct {
+	mockProvider
+	work *service.WorkDetail
+	err  error
+}
+
+func (m *mockWorkProvider) GetWork(_ context.Context, _ string) (*service.WorkDetail, error) {
+	return m.work, m.err
+}
+
+func TestAllProvider_GetWork(t *testing.T) {
+	missing := &mockWorkProvider{mockProvider: mockProvider{id: "missing"}, err: service.ErrNotFound}
+	found := &mockWorkProvider{mockProvider: mockProvider{id: "found"}, work: &service.WorkDetail{ID: "RJ1"}}
+
+	work, err := NewProvider(&mockProvider{id: "plain"}, missing, found).GetWork(context.Background(), "RJ1")
+	if err != nil {
+		t.Fatalf("GetWork failed: %v", err)
+	}
+	if work.ID != "RJ1" {
+		t.Errorf("expected work RJ1, got %+v", work)
+	}
+
+	if _, err := NewProvider(missing).GetWork(context.Background(), "RJ1"); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound when no provider has the work, got %v", err)
+	}
+	if _, err := NewProvider(&mockProvider{id: "plain"}).GetWork(context.Background(), "RJ1"); !errors.Is(err, service.ErrWorkLookupUnsupported) {
+		t.Errorf("expected ErrWorkLookupUnsupported, got %v", err)
+	}
+}
+
+type mockDescribedProvider struct {
+	mockProvider
+	info service.ProviderInfo
+}
+
+func (m *mockDescribedProvider) Describe() service.ProviderInfo { return m.info }
+
+func TestAllProvider_Describe(t *testing.T) {
+	p1 := &mockDescribedProvider{info: service.ProviderInfo{QueryKinds: []service.QueryKind{service.QueryKindID}}}
+	p2 := &mockDescribedProvider{info: service.ProviderInfo{
+		QueryKinds:   []service.QueryKind{service.QueryKindID, service.QueryKindKeyword},
+		Filters:      []service.Filter{service.FilterAge},
+		AdultContent: true,
+	}}
+
+	info := NewProvider(p1, p2, &mockProvider{id: "plain"}).Describe()
+	if len(info.QueryKinds) != 2 {
+		t.Errorf("expected union of 2 query kinds, got %v", info.QueryKinds)
+	}
+	if !info.AdultContent {
+		t.Error("expected adult content support if any sub-provider supports it")
+	}
+	if len(info.Filters) != 1 || info.Filters[0] != service.FilterAge {
+		t.Errorf("expected union of filters, got %v", info.Filters)
+	}
+}
+
+func TestAllProvider_Search_SkipsProvidersWithoutFilterSupport(t *testing.T) {
+	filtered := &mockDescribedProvider{
+		mockProvider: mockProvider{id: "filtered", results: []service.AbsBookMetadata{{Title: "Filtered"}}},
+		info:         service.ProviderInfo{Filters: []service.Filter{service.FilterAge}},
+	}
+	plain := &mockProvider{id: "plain", results: []service.AbsBookMetadata{{Title: "Unfiltered"}}}
+
+	results, err := NewProvider(filtered, plain).Search(context.Background(), service.Query{Text: "q", Filters: service.Filters{Age: service.AgeAll}})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Filtered" {
+		t.Errorf("expected only the filtering provider's results, got %+v", results)
+	}
+}
+
+func TestAllProvider_Search_Ranking(t *testing.T) {
+	p1 := &mockProvider{id: "p1", results: []service.AbsBookMetadata{
+		{Title: "全然関係ない作品", ISBN: "RJ000001"},
+		{Title: "【耳かき】癒やしのオトメ", Author: "Other", ISBN: "RJ000002"},
+	}}
+	p2 := &mockProvider{id: "p2", results: []service.AbsBookMetadata{
+		{Title: "癒やしの乙女", ISBN: "RJ000003"},
+		{Title: "癒やしのおとめ", Author: "Circle A", ISBN: "RJ000004"},
+	}}
+	ap := NewProvider(p1, p2)
+
+	for range 5 {
+		results, err := ap.Search(context.Background(), service.Query{Title: "癒やしのオトメ", Author: "circle a"})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+
+		var got []string
+		for _, r := range results {
+			got = append(got, r.ISBN)
+		}
+		// Katakana and hiragana compare equal, brackets are ignored, and the author
+		// match breaks the tie between the two exact title matches.
+		want := []string{"RJ000004", "RJ000002", "RJ000003", "RJ000001"}
+		if !slices.Equal(got, want) {
+			t.Fatalf("expected order %v, got %v", want, got)
+		}
+		if results[0].Score <= results[1].Score || results[3].Score != 0 {
+			t.Errorf("unexpected scores: %v, %v, %v", results[0].Score, results[1].Score, results[3].Score)
+		}
+	}
+}
+
+func TestAllProvider_Search_RankingCodeMatch(t *testing.T) {
+	p := &codeProvider{mockProvider{id: "p", results: []service.AbsBookMetadata{
+		{Title: "RJ123456 review", ISBN: "RJ999999"},
+		{Title: "Unrelated", ISBN: "RJ123456"},
+	}}}
+
+	results, err := NewProvider(p).Search(context.Background(), service.Query{Text: "[ｒｊ123456] Title"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if results[0].ISBN != "RJ123456" {
+		t.Errorf("expected the exact code match first, got %+v", results)
+	}
+
+	// A longer code is a different code, not a match of its prefix.
+	results, err = NewProvider(p).Search(context.Background(), service.Query{Text: "RJ1234567"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	for _, r := range results {
+		if r.Score >= codeMatchWeight {
+			t.Errorf("expected no code match for a longer code, got %+v", r)
+		}
+	}
+}
+
+func TestAllProvider_Search_MergesDuplicates(t *testing.T) {
+	dl := &mockProvider{id: "dlsite", results: []service.AbsBookMetadata{
+		{Title: "癒やしの乙女", Publisher: "Circle A", ISBN: "RJ000001", Description: "short", Cover: "dl.jpg"},
+		{Title: "別の作品", Publisher: "Circle A", ISBN: "RJ000002"},
+	}}
+	other := &mockProvider{id: "other", results: []service.AbsBookMetadata{
+		// Same code, different spelling of the title.
+		{Title: "【ASMR】癒やしの乙女", ISBN: "rj000001", Description: "long description", Cover: "other.jpg", Narrator: "CV A"},
+		// No code, but the same title and circle.
+		{Title: "別の作品", Author: "circle a", Tags: []string{"tag"}, Explicit: true},
+		// Same title, different circle: a different work.
+		{Title: "別の作品", Author: "Circle B"},
+	}}
+
+	ap := NewProviderWithOptions(Options{FieldPriority: map[string][]string{
+		FieldDescription: {"other"},
+	}}, dl, other)
+
+	results, err := ap.Search(context.Background(), service.Query{Text: "test"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results after merging, got %d: %+v", len(results), results)
+	}
+
+	byCode := make(map[string]service.AbsBookMetadata)
+	for _, r := range results {
+		byCode[r.ISBN] = r
+	}
+	first := byCode["RJ000001"]
+	if first.Title != "癒やしの乙女" || first.Cover != "dl.jpg" {
+		t.Errorf("expected title and cover from the first registered provider, got %+v", first)
+	}
+	if first.Description != "long description" {
+		t.Errorf("expected the prioritised provider's description, got %q", first.Description)
+	}
+	if first.Narrator != "CV A" {
+		t.Errorf("expected missing fields to be filled from other records, got %+v", first)
+	}
+	second := byCode["RJ000002"]
+	if !slices.Equal(second.Tags, []string{"tag"}) || !second.Explicit {
+		t.Errorf("expected title and circle duplicates to merge, got %+v", second)
+	}
+}
+
+func TestAllProvider_Search_KeepsDistinctCodes(t *testing.T) {
+	p1 := &codeProvider{mockProvider{id: "p1", results: []service.AbsBookMetadata{{Title: "Work", Author: "Circle", ISBN: "RJ000001"}}}}
+	p2 := &mockProvider{id: "p2", results: []service.AbsBookMetadata{{Title: "Work", Author: "Circle", ISBN: "RJ000002"}}}
+
+	results, err := NewProvider(p1, p2).Search(context.Background(), service.Query{Text: "Work"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected editions with distinct codes to stay separate, got %+v", results)
+	}
+}
+
+// slowProvider answers after delay, or never if it ignores its context.
+type slowProvider struct {
+	mockProvider
+	delay         time.Duration
+	ignoreContext bool
+}
+
+func (s *slowProvider) Search(ctx context.Context, query service.Query) ([]service.AbsBookMetadata, error) {
+	if s.ignoreContext {
+		time.Sleep(s.delay)
+		return s.mockProvider.Search(ctx, query)
+	}
+	select {
+	case <-time.After(s.delay):
+		return s.mockProvider.Search(ctx, query)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestAllProvider_SearchWithSources_Timeouts(t *testing.T) {
+	fast := &mockProvider{id: "fast", results: []service.AbsBookMetadata{{Title: "Fast"}}}
+	slow := &slowProvider{mockProvider: mockProvider{id: "slow", results: []service.AbsBookMetadata{{Title: "Slow"}}}, delay: time.Second}
+	stuck := &slowProvider{mockProvider: mockProvider{id: "stuck"}, delay: time.Second, ignoreContext: true}
+	failing := &mockProvider{id: "failing", err: errors.New("boom")}
+	empty := &mockProvider{id: "empty"}
+
+	ap := NewProviderWithOptions(Options{ProviderTimeout: 50 * time.Millisecond}, fast, slow, stuck, failing, empty)
+
+	start := time.Now()
+	results, sources, err := ap.SearchWithSources(context.Background(), service.Query{Text: "q"})
+	if err != nil {
+		t.Fatalf("SearchWithSources failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the search to give up on slow providers, took %v", elapsed)
+	}
+	if len(results) != 1 || results[0].Title != "Fast" {
+		t.Errorf("expected only the fast provider's results, got %+v", results)
+	}
+
+	var got []service.SourceState
+	for _, src := range sources {
+		got = append(got, src.State)
+	}
+	want := []service.SourceState{service.SourceOK, service.SourceTimeout, service.SourceTimeout, service.SourceFailed, service.SourceOK}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected states %v, got %v", want, got)
+	}
+	if sources[0].Count != 1 || sources[3].Error != "boom" {
+		t.Errorf("unexpected sources: %+v", sources)
+	}
+}
+
+func TestAllProvider_SearchWithSources_AllFailed(t *testing.T) {
+	ap := NewProvider(&mockProvider{id: "a", err: errors.New("down")}, &mockProvider{id: "b", err: fmt.Errorf("b: %w", service.ErrCircuitOpen)})
+
+	_, _, err := ap.SearchWithSources(context.Background(), service.Query{Text: "q"})
+	if err == nil {
+		t.Fatal("expected an error when every provider failed")
+	}
+	if !errors.Is(err, service.ErrCircuitOpen) {
+		t.Errorf("expected the providers' errors to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package all
+
+import (
+	"slices"
+	"strings"
+
+	"audiobookshelf-asmr-provider/internal/domain/provider/textmatch"
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+// sameTitleThreshold is the title similarity above which two records from different
+// providers with a common circle are considered the same work.
+const sameTitleThreshold = 0.9
+
+// Fields whose source can be chosen through Options.FieldPriority, named after
+// their JSON keys.
+const (
+	FieldTitle         = "title"
+	FieldAuthor        = "author"
+	FieldNarrator      = "narrator"
+	FieldSeries        = "series"
+	FieldDescription   = "description"
+	FieldPublisher     = "publisher"
+	FieldPublishedYear = "publishedYear"
+	FieldGenres        = "genres"
+	FieldTags          = "tags"
+	FieldCover         = "cover"
+	FieldISBN          = "isbn"
+	FieldLanguage      = "language"
+)
+
+// sourced is a search result together with the ID of the provider that returned it.
+type sourced struct {
+	meta     service.AbsBookMetadata
+	provider string
+}
+
+// dedupe merges records that describe the same work into one record each. Records
+// are the same work if they share a product code, or if they come from different
+// providers and have near-identical titles and a common circle or author.
+// Groups keep the position of their first record.
+func (p *Provider) dedupe(records []sourced) []service.AbsBookMetadata {
+	var groups [][]sourced
+	for _, r := range records {
+		i := slices.IndexFunc(groups, func(g []sourced) bool {
+			return slices.ContainsFunc(g, func(other sourced) bool { return p.sameWork(r, other) })
+		})
+		if i < 0 {
+			groups = append(groups, []sourced{r})
+			continue
+		}
+		groups[i] = append(groups[i], r)
+	}
+
+	merged := make([]service.AbsBookMetadata, len(groups))
+	for i, g := range groups {
+		merged[i] = p.merge(g)
+	}
+	return merged
+}
+
+// sameWork reports whether two records describe the same work.
+func (p *Provider) sameWork(a, b sourced) bool {
+	if a.meta.ISBN != "" && strings.EqualFold(a.meta.ISBN, b.meta.ISBN) {
+		return true
+	}
+	// Distinct product codes are distinct works, e.g. the editions of a translated work.
+	if p.isProductID(a.meta.ISBN) && p.isProductID(b.meta.ISBN) {
+		return false
+	}
+	if a.provider == b.provider {
+		return false
+	}
+	if similarity(textmatch.Normalize(a.meta.Title), textmatch.Normalize(b.meta.Title)) < sameTitleThreshold {
+		return false
+	}
+	makers := textmatch.SplitNames(a.meta.Publisher + "," + a.meta.Author)
+	return textmatch.MatchesAnyName(makers, b.meta)
+}
+
+// merge combines the records of one work field by field. For each field the first
+// non-empty value is taken, trying the providers listed for the field in
+// Options.FieldPriority first and the remaining records in their original order after.
+func (p *Provider) merge(group []sourced) service.AbsBookMetadata {
+	if len(group) == 1 {
+		return group[0].meta
+	}
+
+	var m service.AbsBookMetadata
+	m.Title = pick(p.ordered(group, FieldTitle), func(r service.AbsBookMetadata) string { return r.Title })
+	m.Author = pick(p.ordered(group, FieldAuthor), func(r service.AbsBookMetadata) string { return r.Author })
+	m.Narrator = pick(p.ordered(group, FieldNarrator), func(r service.AbsBookMetadata) string { return r.Narrator })
+	m.Series = pickSlice(p.ordered(group, FieldSeries), func(r service.AbsBookMetadata) []service.SeriesMetadata { return r.Series })
+	m.Description = pick(p.ordered(group, FieldDescription), func(r service.AbsBookMetadata) string { return r.Description })
+	m.Publisher = pick(p.ordered(group, FieldPublisher), func(r service.AbsBookMetadata) string { return r.Publisher })
+	m.PublishedYear = pick(p.ordered(group, FieldPublishedYear), func(r service.AbsBookMetadata) string { return r.PublishedYear })
+	m.Genres = pickSlice(p.ordered(group, FieldGenres), func(r service.AbsBookMetadata) []string { return r.Genres })
+	m.Tags = pickSlice(p.ordered(group, FieldTags), func(r service.AbsBookMetadata) []string { return r.Tags })
+	m.Cover = pick(p.ordered(group, FieldCover), func(r service.AbsBookMetadata) string { return r.Cover })
+	m.ISBN = pick(p.ordered(group, FieldISBN), func(r service.AbsBookMetadata) string { return r.ISBN })
+	m.Language = pick(p.ordered(group, FieldLanguage), func(r service.AbsBookMetadata) string { return r.Language })
+
+	// A work is explicit if any source says so.
+	for _, r := range group {
+		m.Explicit = m.Explicit || r.meta.Explicit
+	}
+	return m
+}
+
+// ordered returns the group's records in the priority order configured for field.
+func (p *Provider) ordered(group []sourced, field string) []service.AbsBookMetadata {
+	priority := p.opts.FieldPriority[field]
+	rankOf := func(r sourced) int {
+		if i := slices.Index(priority, r.provider); i >= 0 {
+			return i
+		}
+		return len(priority)
+	}
+
+	sorted := slices.Clone(group)
+	slices.SortStableFunc(sorted, func(a, b sourced) int { return rankOf(a) - rankOf(b) })
+
+	metas := make([]service.AbsBookMetadata, len(sorted))
+	for i, r := range sorted {
+		metas[i] = r.meta
+	}
+	return metas
+}
+
+func pick(records []service.AbsBookMetadata, get func(service.AbsBookMetadata) string) string {
+	for _, r := range records {
+		if v := get(r); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func pickSlice[T any](records []service.AbsBookMetadata, get func(service.AbsBookMetadata) []T) []T {
+	for _, r := range records {
+		if v := get(r); len(v) > 0 {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package all
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+
+	"audiobookshelf-asmr-provider/internal/domain/provider/textmatch"
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+// Weights of the relevance signals. An exact product code match outranks any
+// combination of the other signals.
+const (
+	codeMatchWeight   = 10
+	titleMatchWeight  = 5
+	authorMatchWeight = 2
+)
+
+// scored is a result together with its relevance and its position in provider order.
+type scored struct {
+	meta  service.AbsBookMetadata
+	score float64
+	order int
+}
+
+// rank scores results against the query and sorts them by descending score.
+// Ties keep the order in which they were given, so that the output is deterministic
+// as long as the input is. The score is recorded in each result's Score field.
+func (p *Provider) rank(results []service.AbsBookMetadata, query service.Query) []service.AbsBookMetadata {
+	codes := p.productIDs(query.Term())
+	title := textmatch.Normalize(query.Title)
+	if title == "" {
+		title = textmatch.Normalize(query.Term())
+	}
+	authors := textmatch.SplitNames(query.Author)
+
+	items := make([]scored, len(results))
+	for i, r := range results {
+		score := 0.0
+		if codes[strings.ToUpper(r.ISBN)] {
+			score += codeMatchWeight
+		}
+		score += titleMatchWeight * similarity(title, textmatch.Normalize(r.Title))
+		if textmatch.MatchesAnyName(authors, r) {
+			score += authorMatchWeight
+		}
+		items[i] = scored{meta: r, score: math.Round(score*1000) / 1000, order: i}
+	}
+
+	slices.SortStableFunc(items, func(a, b scored) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.order, b.order))
+	})
+
+	ranked := make([]service.AbsBookMetadata, len(items))
+	for i, item := range items {
+		ranked[i] = item.meta
+		ranked[i].Score = item.score
+	}
+	return ranked
+}
+
+// similarity returns the Dice coefficient of the character bigrams of a and b,
+// which works for Japanese text without word boundaries.
+func similarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ba, bb := bigrams(a), bigrams(b)
+	if len(ba) == 0 || len(bb) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(ba))
+	for _, g := range ba {
+		counts[g]++
+	}
+	common := 0
+	for _, g := range bb {
+		if counts[g] > 0 {
+			counts[g]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(ba)+len(bb))
+}
+
+func bigrams(s string) []string {
+	runes := []rune(s)
+	if len(runes) < 2 {
+		return nil
+	}
+	grams := make([]string, 0, len(runes)-1)
+	for i := 0; i < len(runes)-1; i++ {
+		grams = append(grams, string(runes[i:i+2]))
+	}
+	return grams
+}
+
+// productIDs returns the product IDs that the providers recognise in s, upper-cased.
+func (p *Provider) productIDs(s string) map[string]bool {
+	ids := make(map[string]bool)
+	for _, pr := range p.providers {
+		if f, ok := pr.(service.ProductIDFinder); ok {
+			if id, found := f.FindProductID(s); found {
+				ids[strings.ToUpper(id)] = true
+			}
+		}
+	}
+	return ids
+}
+
+// isProductID reports whether a provider recognises id as one of its product IDs.
+func (p *Provider) isProductID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, pr := range p.providers {
+		if f, ok := pr.(service.ProductIDFinder); ok {
+			if found, ok := f.FindProductID(id); ok && strings.EqualFold(found, id) {
+				return true
+			}
+		}
+	}
+	return false
+}
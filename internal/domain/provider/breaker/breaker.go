@@ -0,0 +1,196 @@
+// Package breaker guards providers with a circuit breaker.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultCooldown         = 30 * time.Second
+)
+
+// Options tunes a circuit breaker. Zero values select the defaults.
+type Options struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a probe request is let through.
+	Cooldown time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = defaultFailureThreshold
+	}
+	if o.Cooldown <= 0 {
+		o.Cooldown = defaultCooldown
+	}
+	return o
+}
+
+// Provider wraps a provider with a circuit breaker. After FailureThreshold
+// consecutive failures the circuit opens and requests fail fast with an error
+// wrapping service.ErrCircuitOpen, which lets the service serve stale cache entries
+// instead of waiting for an upstream that is down. Once the cooldown has passed, a
+// single probe request is let through (half-open): its success closes the circuit,
+// its failure opens it for another cooldown.
+//
+// Not-found results, cancelled requests and access denied by the upstream (e.g. an
+// age gate) count as neither success nor failure.
+type Provider struct {
+	inner service.Provider
+	opts  Options
+	now   func() time.Time
+
+	mu       sync.Mutex
+	state    service.CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// Wrap guards p with a circuit breaker.
+func Wrap(p service.Provider, opts Options) *Provider {
+	return &Provider{
+		inner: p,
+		opts:  opts.withDefaults(),
+		now:   time.Now,
+		state: service.CircuitClosed,
+	}
+}
+
+// ID returns the ID of the wrapped provider.
+func (b *Provider) ID() string {
+	return b.inner.ID()
+}
+
+// CacheTTL returns the cache TTL of the wrapped provider.
+func (b *Provider) CacheTTL() time.Duration {
+	return b.inner.CacheTTL()
+}
+
+// Describe returns the wrapped provider's description, if it has one.
+func (b *Provider) Describe() service.ProviderInfo {
+	if d, ok := b.inner.(service.Describer); ok {
+		return d.Describe()
+	}
+	return service.ProviderInfo{Name: b.inner.ID()}
+}
+
+// ParserStats returns the parser validation counters of the wrapped provider, if
+// it scrapes pages.
+func (b *Provider) ParserStats() service.ParserStats {
+	if r, ok := b.inner.(service.ParserReporter); ok {
+		return r.ParserStats()
+	}
+	return service.ParserStats{}
+}
+
+// FindProductID finds a product ID of the wrapped provider in s, if the provider
+// has product IDs.
+func (b *Provider) FindProductID(s string) (string, bool) {
+	if f, ok := b.inner.(service.ProductIDFinder); ok {
+		return f.FindProductID(s)
+	}
+	return "", false
+}
+
+// Search searches the wrapped provider unless the circuit is open.
+func (b *Provider) Search(ctx context.Context, query service.Query) ([]service.AbsBookMetadata, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	matches, err := b.inner.Search(ctx, query)
+	b.record(err)
+	return matches, err
+}
+
+// GetWork looks up a work on the wrapped provider unless the circuit is open.
+func (b *Provider) GetWork(ctx context.Context, id string) (*service.WorkDetail, error) {
+	fetcher, ok := b.inner.(service.WorkFetcher)
+	if !ok {
+		return nil, service.ErrWorkLookupUnsupported
+	}
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	work, err := fetcher.GetWork(ctx, id)
+	b.record(err)
+	return work, err
+}
+
+// CircuitState reports the current state of the circuit.
+func (b *Provider) CircuitState() service.CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == service.CircuitOpen && b.now().Sub(b.openedAt) >= b.opts.Cooldown {
+		// The next request will probe.
+		return service.CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a request may go upstream, turning the first request after
+// the cooldown into the half-open probe.
+func (b *Provider) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case service.CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.opts.Cooldown {
+			return fmt.Errorf("%s: %w", b.inner.ID(), service.ErrCircuitOpen)
+		}
+		slog.Info("Circuit half-open, probing provider", "provider", b.inner.ID())
+		b.state = service.CircuitHalfOpen
+		b.probing = true
+		return nil
+	case service.CircuitHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%s: %w", b.inner.ID(), service.ErrCircuitOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record updates the circuit after a request that was allowed through.
+func (b *Provider) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if errors.Is(err, service.ErrNotFound) || errors.Is(err, context.Canceled) || errors.Is(err, service.ErrAccessDenied) {
+		if b.state == service.CircuitHalfOpen {
+			// Inconclusive probe: let the next request probe again.
+			b.probing = false
+		}
+		return
+	}
+
+	if err == nil {
+		if b.state != service.CircuitClosed {
+			slog.Info("Circuit closed, provider recovered", "provider", b.inner.ID())
+		}
+		b.state = service.CircuitClosed
+		b.failures = 0
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.state == service.CircuitHalfOpen || b.failures >= b.opts.FailureThreshold {
+		if b.state != service.CircuitOpen {
+			slog.Warn("Circuit opened, failing fast", "provider", b.inner.ID(), "failures", b.failures, "cooldown", b.opts.Cooldown, "error", err)
+		}
+		b.state = service.CircuitOpen
+		b.openedAt = b.now()
+		b.probing = false
+	}
+}
@@ -0,0 +1,205 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"audiobookshelf-asmr-provider/internal/domain/provider/dlsite"
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+type mockProvider struct {
+	calls int
+	err   error
+}
+
+func (m *mockProvider) ID() string              { return "mock" }
+func (m *mockProvider) CacheTTL() time.Duration { return time.Hour }
+func (m *mockProvider) Search(_ context.Context, _ service.Query) ([]service.AbsBookMetadata, error) {
+	m.calls++
+	return nil, m.err
+}
+
+// newTestBreaker returns a breaker with a controllable clock.
+func newTestBreaker(inner service.Provider) (*Provider, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := Wrap(inner, Options{FailureThreshold: 3, Cooldown: time.Minute})
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func search(b *Provider) error {
+	_, err := b.Search(context.Background(), service.Query{Text: "q"})
+	return err
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	inner := &mockProvider{err: errors.New("upstream down")}
+	b, _ := newTestBreaker(inner)
+
+	for i := range 3 {
+		if b.CircuitState() != service.CircuitClosed {
+			t.Fatalf("expected the circuit to stay closed after %d failures", i)
+		}
+		_ = search(b)
+	}
+	if b.CircuitState() != service.CircuitOpen {
+		t.Fatalf("expected the circuit to open, got %s", b.CircuitState())
+	}
+
+	if err := search(b); !errors.Is(err, service.ErrCircuitOpen) {
+		t.Errorf("expected a fail-fast error, got %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("expected no upstream call while open, got %d calls", inner.calls)
+	}
+}
+
+func TestBreaker_SuccessResetsFailures(t *testing.T) {
+	inner := &mockProvider{err: errors.New("flaky")}
+	b, _ := newTestBreaker(inner)
+
+	_ = search(b)
+	_ = search(b)
+	inner.err = nil
+	_ = search(b)
+	inner.err = errors.New("flaky")
+	_ = search(b)
+	_ = search(b)
+
+	if b.CircuitState() != service.CircuitClosed {
+		t.Errorf("expected non-consecutive failures to keep the circuit closed, got %s", b.CircuitState())
+	}
+}
+
+func TestBreaker_IgnoresNotFoundAndCancellation(t *testing.T) {
+	inner := &mockProvider{err: fmt.Errorf("gone: %w", service.ErrNotFound)}
+	b, _ := newTestBreaker(inner)
+	for range 5 {
+		_ = search(b)
+	}
+	inner.err = context.Canceled
+	for range 5 {
+		_ = search(b)
+	}
+	if b.CircuitState() != service.CircuitClosed {
+		t.Errorf("expected the circuit to stay closed, got %s", b.CircuitState())
+	}
+}
+
+func TestBreaker_Interstitials(t *testing.T) {
+	inner := &mockProvider{}
+	b, _ := newTestBreaker(inner)
+	for _, denied := range []error{dlsite.ErrAgeGate, dlsite.ErrLoginRequired, dlsite.ErrRegionBlocked} {
+		inner.err = &dlsite.InterstitialError{Err: denied, URL: "https://www.dlsite.com/maniax/work/=/product_id/RJ01234567.html"}
+		for range 5 {
+			_ = search(b)
+		}
+	}
+	if b.CircuitState() != service.CircuitClosed {
+		t.Fatalf("expected denied access to keep the circuit closed, got %s", b.CircuitState())
+	}
+
+	inner.err = &dlsite.InterstitialError{Err: dlsite.ErrMaintenance}
+	for range 3 {
+		_ = search(b)
+	}
+	if b.CircuitState() != service.CircuitOpen {
+		t.Errorf("expected maintenance to open the circuit, got %s", b.CircuitState())
+	}
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	inner := &mockProvider{err: errors.New("upstream down")}
+	b, now := newTestBreaker(inner)
+	for range 3 {
+		_ = search(b)
+	}
+
+	*now = now.Add(time.Minute)
+	if b.CircuitState() != service.CircuitHalfOpen {
+		t.Fatalf("expected the circuit to be half-open after the cooldown, got %s", b.CircuitState())
+	}
+
+	// A failed probe opens the circuit for another cooldown.
+	if err := search(b); errors.Is(err, service.ErrCircuitOpen) {
+		t.Fatal("expected the probe to go upstream")
+	}
+	if b.CircuitState() != service.CircuitOpen {
+		t.Fatalf("expected a failed probe to reopen the circuit, got %s", b.CircuitState())
+	}
+	if err := search(b); !errors.Is(err, service.ErrCircuitOpen) {
+		t.Errorf("expected a fail-fast error after the failed probe, got %v", err)
+	}
+
+	// A successful probe closes it.
+	*now = now.Add(time.Minute)
+	inner.err = nil
+	if err := search(b); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if b.CircuitState() != service.CircuitClosed {
+		t.Errorf("expected a successful probe to close the circuit, got %s", b.CircuitState())
+	}
+}
+
+func TestBreaker_SingleProbe(t *testing.T) {
+	inner := &mockProvider{err: errors.New("upstream down")}
+	b, now := newTestBreaker(inner)
+	for range 3 {
+		_ = search(b)
+	}
+	*now = now.Add(time.Minute)
+
+	// Hold the circuit half-open as if a probe were in flight.
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected the probe to be allowed, got %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, service.ErrCircuitOpen) {
+		t.Errorf("expected requests during the probe to fail fast, got %v", err)
+	}
+}
+
+func TestBreaker_GetWorkUnsupported(t *testing.T) {
+	b, _ := newTestBreaker(&mockProvider{})
+	if _, err := b.GetWork(context.Background(), "RJ000001"); !errors.Is(err, service.ErrWorkLookupUnsupported) {
+		t.Errorf("expected ErrWorkLookupUnsupported, got %v", err)
+	}
+}
+
+type parsingProvider struct {
+	mockProvider
+}
+
+func (p *parsingProvider) ParserStats() service.ParserStats {
+	return service.ParserStats{State: service.ParserDegraded}
+}
+
+func TestBreaker_ForwardsParserStats(t *testing.T) {
+	if got := Wrap(&parsingProvider{}, Options{}).ParserStats().State; got != service.ParserDegraded {
+		t.Errorf("expected the wrapped parser state, got %q", got)
+	}
+	if got := Wrap(&mockProvider{}, Options{}).ParserStats().State; got != "" {
+		t.Errorf("expected no parser state for a provider without a parser, got %q", got)
+	}
+}
+
+type codeProvider struct {
+	mockProvider
+}
+
+func (p *codeProvider) FindProductID(s string) (string, bool) {
+	return "RJ01234567", true
+}
+
+func TestBreaker_ForwardsFindProductID(t *testing.T) {
+	if id, ok := Wrap(&codeProvider{}, Options{}).FindProductID("[RJ01234567] Title"); !ok || id != "RJ01234567" {
+		t.Errorf("expected the wrapped provider's product ID, got %q, %v", id, ok)
+	}
+	if _, ok := Wrap(&mockProvider{}, Options{}).FindProductID("[RJ01234567] Title"); ok {
+		t.Error("expected no product ID for a provider without product IDs")
+	}
+}
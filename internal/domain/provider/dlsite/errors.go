@@ -0,0 +1,57 @@
+package dlsite
+
+import (
+	"errors"
+	"fmt"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+// NotFoundError is returned when DLsite responds with 404 for a page,
+// typically because the work has been delisted or never existed.
+type NotFoundError struct {
+	URL string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("dlsite: page not found: %s", e.URL)
+}
+
+// Is reports whether target is service.ErrNotFound, so callers can match the
+// error without depending on this package.
+func (e *NotFoundError) Is(target error) bool {
+	return target == service.ErrNotFound
+}
+
+// Errors identifying the pages DLsite serves in place of the requested one. They are
+// returned wrapped in an InterstitialError.
+var (
+	ErrAgeGate       = errors.New("dlsite: age verification page served (set DISABLE_AGE_CHECK to bypass it)")
+	ErrLoginRequired = errors.New("dlsite: login required")
+	ErrMaintenance   = errors.New("dlsite: under maintenance")
+	ErrRegionBlocked = errors.New("dlsite: not available in this region")
+)
+
+// InterstitialError is returned when DLsite answers with an interstitial page, such
+// as an age gate or a maintenance notice, instead of the requested page. These pages
+// carry no metadata, so they must not be mistaken for an empty work or result list.
+type InterstitialError struct {
+	// Err is one of ErrAgeGate, ErrLoginRequired, ErrMaintenance or ErrRegionBlocked.
+	Err error
+	URL string
+}
+
+func (e *InterstitialError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, e.URL)
+}
+
+func (e *InterstitialError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is service.ErrAccessDenied for the interstitials that
+// persist until the setup changes: the age gate, the login wall and the region block.
+// A maintenance notice is an outage and not a denial.
+func (e *InterstitialError) Is(target error) bool {
+	return target == service.ErrAccessDenied && e.Err != ErrMaintenance
+}
@@ -0,0 +1,78 @@
+package dlsite
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Markers of DLsite's interstitial pages. Text markers are only looked for in the
+// title of pages without work or search content, since titles carry work names
+// and search keywords; the region block notice is only looked for in the body of
+// a 403 page, as a whole sentence, since "region" or "country" alone appear on
+// ordinary error pages too.
+var (
+	ageGateSelectors   = "#adult_check_box, .adult_check_box, .adultcheck, #adultcheck"
+	ageGateTitles      = []string{"年齢認証", "Age Verification", "年龄认证", "年齡認證", "연령 인증"}
+	loginSelectors     = "#login_form, form.login_form"
+	maintenanceTitles  = []string{"メンテナンス", "Maintenance", "维护", "維護", "점검"}
+	regionBlockedTexts = []string{
+		"お住まいの地域ではご利用いただけません",
+		"not available in your country or region",
+		"not available in your region",
+		"您所在的地区无法使用",
+	}
+
+	// contentSelectors match the content of work and search pages. Their titles
+	// carry the work title or the search keyword, which may contain any marker.
+	contentSelectors = "#work_name, #search_result_list, .n_worklist"
+)
+
+// detectInterstitial reports the interstitial page that DLsite served instead of
+// the requested one, if any. finalURL is the URL after redirects; doc may be nil
+// when the body was not parsed.
+func detectInterstitial(status int, finalURL *url.URL, doc *goquery.Document) error {
+	if finalURL != nil && (strings.HasPrefix(finalURL.Host, "login.") || strings.Contains(finalURL.Path, "/login")) {
+		return ErrLoginRequired
+	}
+
+	var title, body string
+	if doc != nil {
+		title = doc.Find("title").First().Text()
+		body = doc.Find("body").Text()
+	}
+
+	switch {
+	case status == http.StatusUnavailableForLegalReasons:
+		return ErrRegionBlocked
+	case status == http.StatusForbidden && containsAnyFold(body, regionBlockedTexts):
+		return ErrRegionBlocked
+	case containsAnyFold(title, maintenanceTitles) && (status != http.StatusOK || !hasContent(doc)):
+		return ErrMaintenance
+	case doc == nil:
+		return nil
+	case doc.Find(ageGateSelectors).Length() > 0 || containsAnyFold(title, ageGateTitles) && !hasContent(doc):
+		return ErrAgeGate
+	case doc.Find(loginSelectors).Length() > 0 && doc.Find("#work_name").Length() == 0:
+		return ErrLoginRequired
+	}
+	return nil
+}
+
+// hasContent reports whether doc is a work or search page rather than an interstitial.
+func hasContent(doc *goquery.Document) bool {
+	return doc != nil && doc.Find(contentSelectors).Length() > 0
+}
+
+// containsAnyFold reports whether s contains any of the substrings, ignoring case.
+func containsAnyFold(s string, substrs []string) bool {
+	s = strings.ToLower(s)
+	for _, sub := range substrs {
+		if strings.Contains(s, strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package dlsite
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+func TestDLsiteFetcher_Interstitials(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error
+	}{
+		{
+			name: "age gate",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`<html><head><title>年齢認証 | DLsite</title></head><body><div class="adult_check_box">18歳以上ですか？</div></body></html>`))
+			},
+			want: ErrAgeGate,
+		},
+		{
+			name: "login redirect",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/login") {
+					_, _ = w.Write([]byte(`<html><body><form id="login_form"></form></body></html>`))
+					return
+				}
+				http.Redirect(w, r, "/login/=/skip_register/1", http.StatusFound)
+			},
+			want: ErrLoginRequired,
+		},
+		{
+			name: "login form",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`<html><body><form class="login_form"></form></body></html>`))
+			},
+			want: ErrLoginRequired,
+		},
+		{
+			name: "maintenance",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`<html><head><title>メンテナンス中 | DLsite</title></head><body>ただいまメンテナンス中です。</body></html>`))
+			},
+			want: ErrMaintenance,
+		},
+		{
+			name: "region block",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`<html><body>This service is not available in your region.</body></html>`))
+			},
+			want: ErrRegionBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			f := newTestFetcher(server.URL)
+
+			_, err := f.GetWork(context.Background(), "RJ123456")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			var ie *InterstitialError
+			if !errors.As(err, &ie) || errors.Is(err, service.ErrNotFound) {
+				t.Errorf("expected an InterstitialError that is not a not-found error, got %#v", err)
+			}
+
+			if _, err := f.Search(context.Background(), service.Query{Text: "RJ123456"}); !errors.Is(err, tt.want) {
+				t.Errorf("Search: expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDLsiteFetcher_Interstitials_OrdinaryErrors(t *testing.T) {
+	bodies := []string{
+		`<html><body>Forbidden</body></html>`,
+		// Words of the region block notice on an ordinary error page.
+		`<html><body>Access denied. Select your country: Japan / 地域を選択</body></html>`,
+	}
+	for _, body := range bodies {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(body))
+		}))
+
+		_, err := newTestFetcher(server.URL).GetWork(context.Background(), "RJ123456")
+		var ie *InterstitialError
+		if err == nil || errors.As(err, &ie) {
+			t.Errorf("%s: expected a plain status error, got %v", body, err)
+		}
+		server.Close()
+	}
+}
+
+func TestDLsiteFetcher_Search_KeywordEnrichmentInterstitial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/fsr/") {
+			_, _ = w.Write([]byte(`<html><body><table id="search_result_list"><tr>
+				<td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ999999.html">Match</a></td>
+			</tr></table></body></html>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<html><head><title>Age Verification</title></head><body></body></html>`))
+	}))
+	defer server.Close()
+
+	_, err := newTestFetcher(server.URL).Search(context.Background(), service.Query{Text: "keyword"})
+	if !errors.Is(err, ErrAgeGate) {
+		t.Errorf("expected the work page's age gate to fail the search, got %v", err)
+	}
+}
+
+func TestDLsiteFetcher_MaintenanceInTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/fsr/") {
+			_, _ = w.Write([]byte(`<html><head><title>「メンテナンス」の検索結果 | DLsite</title></head><body><table id="search_result_list"><tr>
+				<td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ123456.html">耳かきメンテナンス</a></td>
+			</tr></table></body></html>`))
+			return
+		}
+		if strings.Contains(r.URL.Path, "/work/") {
+			_, _ = w.Write([]byte(`<html><head><title>耳かきメンテナンス [Circle] | DLsite</title></head><body><h1 id="work_name">耳かきメンテナンス</h1></body></html>`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	f := newTestFetcher(server.URL)
+
+	work, err := f.GetWork(context.Background(), "RJ123456")
+	if err != nil || work.Title != "耳かきメンテナンス" {
+		t.Fatalf("expected the work titled like a maintenance page, got %+v, %v", work, err)
+	}
+	matches, err := f.Search(context.Background(), service.Query{Text: "メンテナンス"})
+	if err != nil || len(matches) != 1 {
+		t.Errorf("expected the keyword search to find the work, got %+v, %v", matches, err)
+	}
+}
+
+func TestDLsiteFetcher_AgeGateInTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/fsr/") {
+			_, _ = w.Write([]byte(`<html><head><title>「年齢認証」の検索結果 | DLsite</title></head><body><table id="search_result_list"><tr>
+				<td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ123456.html">年齢認証ごっこ</a></td>
+			</tr></table></body></html>`))
+			return
+		}
+		if strings.Contains(r.URL.Path, "/work/") {
+			_, _ = w.Write([]byte(`<html><head><title>年齢認証ごっこ [Circle] | DLsite</title></head><body><h1 id="work_name">年齢認証ごっこ</h1></body></html>`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	f := newTestFetcher(server.URL)
+
+	work, err := f.GetWork(context.Background(), "RJ123456")
+	if err != nil || work.Title != "年齢認証ごっこ" {
+		t.Fatalf("expected the work titled like an age gate, got %+v, %v", work, err)
+	}
+	matches, err := f.Search(context.Background(), service.Query{Text: "年齢認証"})
+	if err != nil || len(matches) != 1 {
+		t.Errorf("expected the keyword search to find the work, got %+v, %v", matches, err)
+	}
+}
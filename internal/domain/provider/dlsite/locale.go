@@ -0,0 +1,179 @@
+package dlsite
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultLocale is the locale DLsite serves when none is requested.
+const defaultLocale = "ja_JP"
+
+// supportedLocales are the locales DLsite offers through its "locale" parameter.
+var supportedLocales = map[string]bool{
+	"ja_JP": true,
+	"en_US": true,
+	"zh_CN": true,
+	"zh_TW": true,
+	"ko_KR": true,
+}
+
+// optionLanguages maps the language flags found in a product's options to the
+// language names reported to Audiobookshelf.
+var optionLanguages = map[string]string{
+	"JPN":      "Japanese",
+	"ENG":      "English",
+	"CHI_HANS": "Chinese (Simplified)",
+	"CHI_HANT": "Chinese (Traditional)",
+	"KO_KR":    "Korean",
+}
+
+// pageLanguages maps the language names shown on work pages to the names reported
+// to Audiobookshelf.
+var pageLanguages = map[string]string{
+	"日本語":      "Japanese",
+	"Japanese": "Japanese",
+	"英語":       "English",
+	"English":  "English",
+	"簡体中文":     "Chinese (Simplified)",
+	"简体中文":     "Chinese (Simplified)",
+	"繁体中文":     "Chinese (Traditional)",
+	"繁體中文":     "Chinese (Traditional)",
+	"韓国語":      "Korean",
+	"한국어":      "Korean",
+	"Korean":   "Korean",
+}
+
+// Labels of the "#work_outline" rows in every supported locale.
+var (
+	voiceActorHeaders  = []string{"声優", "Voice Actor", "声优", "聲優", "성우"}
+	genreHeaders       = []string{"ジャンル", "Genre", "分类", "分類", "장르"}
+	releaseDateHeaders = []string{"販売日", "発売日", "Release date", "贩卖日", "販賣日", "판매일"}
+	seriesHeaders      = []string{"シリーズ", "Series", "系列", "시리즈"}
+	scenarioHeaders    = []string{"シナリオ", "Scenario", "剧情", "劇情", "시나리오"}
+	workFormatHeaders  = []string{"作品形式", "Product format", "作品类型", "作品類型", "작품 형식"}
+	ageRatingHeaders   = []string{"年齢指定", "Age", "年龄指定", "年齡指定", "연령 지정"}
+	authorHeaders      = []string{"著者", "作者", "Author", "저자"}
+	languageHeaders    = []string{"対応言語", "Supported languages", "对应语言", "對應語言", "대응 언어"}
+
+	// ignoredHeaders are the labels of rows that are known but not extracted.
+	ignoredHeaders = []string{
+		"更新情報", "Update information", "更新信息", "更新資訊", "갱신 정보",
+		"イラスト", "Illustration", "插画", "插畫", "일러스트",
+		"音楽", "Music", "音乐", "音樂", "음악",
+		"ファイル形式", "File format", "文件形式", "檔案形式", "파일 형식",
+		"ファイル容量", "File size", "文件容量", "檔案容量", "파일 용량",
+		"その他", "Misc", "其他", "기타",
+		"イベント", "Event", "活动", "活動", "이벤트",
+	}
+)
+
+// outlineHeaders are the label lists of every known "#work_outline" row.
+var outlineHeaders = [][]string{
+	voiceActorHeaders, genreHeaders, releaseDateHeaders, seriesHeaders, scenarioHeaders,
+	workFormatHeaders, ageRatingHeaders, authorHeaders, languageHeaders, ignoredHeaders,
+}
+
+// allAgesLabels are the localized labels of the all-ages rating.
+var allAgesLabels = []string{"全年齢", "All Ages", "全年龄", "전연령"}
+
+// normalizeLocale returns locale if DLsite supports it and ok=false otherwise.
+// Both "en_US" and "en-us" spellings are accepted.
+func normalizeLocale(locale string) (string, bool) {
+	lang, region, found := strings.Cut(strings.ReplaceAll(strings.TrimSpace(locale), "-", "_"), "_")
+	if !found {
+		return "", false
+	}
+	locale = strings.ToLower(lang) + "_" + strings.ToUpper(region)
+	return locale, supportedLocales[locale]
+}
+
+// withLocale adds the fetcher's locale to a DLsite URL. The default locale is
+// left implicit so that URLs stay unchanged for Japanese.
+func (f *dlsiteFetcher) withLocale(rawURL string) string {
+	if f.locale == "" || f.locale == defaultLocale {
+		return rawURL
+	}
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + "locale=" + url.QueryEscape(f.locale)
+}
+
+// containsAny reports whether s contains any of the substrings.
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAllAges reports whether an age rating label denotes an all-ages work.
+func isAllAges(rating string) bool {
+	return containsAny(rating, allAgesLabels)
+}
+
+// languagesFromOptions extracts the languages flagged in a product's options
+// string, e.g. "JPN#DLP#REV".
+func languagesFromOptions(options string) []string {
+	var langs []string
+	for _, opt := range strings.Split(options, "#") {
+		if lang, ok := optionLanguages[strings.TrimSpace(opt)]; ok {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// queryLanguages maps the spellings accepted for a preferred language (language
+// and locale codes, DLsite's language flags) to the names reported to Audiobookshelf.
+var queryLanguages = map[string]string{
+	"ja": "Japanese", "ja_jp": "Japanese", "jpn": "Japanese",
+	"en": "English", "en_us": "English", "eng": "English",
+	"zh": "Chinese (Simplified)", "zh_cn": "Chinese (Simplified)", "zh_hans": "Chinese (Simplified)", "chi_hans": "Chinese (Simplified)",
+	"zh_tw": "Chinese (Traditional)", "zh_hant": "Chinese (Traditional)", "chi_hant": "Chinese (Traditional)",
+	"ko": "Korean", "ko_kr": "Korean",
+}
+
+// parseLanguage resolves a preferred language given as a code (e.g. "en", "zh-TW",
+// "ENG") or name (e.g. "English") to the name used for work languages.
+func parseLanguage(s string) (string, bool) {
+	key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "-", "_"))
+	if lang, ok := queryLanguages[key]; ok {
+		return lang, true
+	}
+	for _, lang := range optionLanguages {
+		if strings.EqualFold(lang, strings.TrimSpace(s)) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+var dateNumbersRegex = regexp.MustCompile(`(\d{4})\D+(\d{1,2})\D+(\d{1,2})`)
+
+// normalizeDate converts a release date as shown on a work page (e.g. "2023年01月01日",
+// "2023/01/01" or "Jan/01/2023") to YYYY-MM-DD. Unknown formats are returned trimmed.
+func normalizeDate(s string) string {
+	s = strings.TrimSpace(s)
+	if m := dateNumbersRegex.FindStringSubmatch(s); m != nil {
+		return m[1] + "-" + leftPad(m[2]) + "-" + leftPad(m[3])
+	}
+	for _, layout := range []string{"Jan/02/2006", "01/02/2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return s
+}
+
+func leftPad(s string) string {
+	if len(s) == 1 {
+		return "0" + s
+	}
+	return s
+}
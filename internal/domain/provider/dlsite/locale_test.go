@@ -0,0 +1,103 @@
+package dlsite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"en_US", "en_US", true},
+		{"en-us", "en_US", true},
+		{" zh_TW ", "zh_TW", true},
+		{"fr_FR", "fr_FR", false},
+		{"english", "", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			got, ok := normalizeLocale(tc.input)
+			if got != tc.want || ok != tc.ok {
+				t.Errorf("normalizeLocale(%q) = (%q, %v), want (%q, %v)", tc.input, got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestNormalizeDate(t *testing.T) {
+	tests := map[string]string{
+		"2023年01月01日":   "2023-01-01",
+		"2023年1月5日 16時": "2023-01-05",
+		"2023/01/02":    "2023-01-02",
+		"Mar/15/2024":   "2024-03-15",
+		"03/15/2024":    "2024-03-15",
+		"2024년 03월 15일": "2024-03-15",
+		"not a date":    "not a date",
+	}
+
+	for input, want := range tests {
+		if got := normalizeDate(input); got != want {
+			t.Errorf("normalizeDate(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestDLsiteFetcher_Search_Locale(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("locale"); got != "en_US" {
+			t.Errorf("expected locale=en_US on %s, got %q", r.URL.Path, got)
+		}
+		switch r.URL.Path {
+		case productInfoPath:
+			_, _ = w.Write([]byte(`{"RJ010101": {"work_name": "English Title", "age_category": 1, "options": "ENG#JPN"}}`))
+		case "/maniax/work/=/product_id/RJ010101.html":
+			_, _ = w.Write([]byte(`<html><body>
+				<h1 id="work_name">English Title</h1>
+				<table id="work_outline">
+					<tr><th>Release date</th><td><a href="#">Mar/15/2024</a></td></tr>
+					<tr><th>Voice Actor</th><td><a href="#">Actor</a></td></tr>
+					<tr><th>Genre</th><td><a href="#">Healing</a></td></tr>
+					<tr><th>Age</th><td><a href="#">All Ages</a></td></tr>
+				</table>
+			</body></html>`))
+		default:
+			t.Errorf("unexpected request path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+	f.locale = "en_US"
+
+	results, err := f.Search(context.Background(), service.Query{Text: "RJ010101"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+
+	r := results[0]
+	if r.Title != "English Title" || r.Narrator != "Actor" || r.PublishedYear != "2024" {
+		t.Errorf("unexpected metadata: %+v", r)
+	}
+	if want := []string{"Healing"}; !reflect.DeepEqual(r.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, r.Tags)
+	}
+	if r.Language != "English" {
+		t.Errorf("expected language English, got %q", r.Language)
+	}
+	if r.Explicit {
+		t.Error("expected all-ages work not to be explicit")
+	}
+}
@@ -0,0 +1,27 @@
+package dlsite
+
+import (
+	"audiobookshelf-asmr-provider/internal/domain/provider/textmatch"
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+// preferAuthor moves results whose circle or voice actors match author to the front,
+// keeping the relative order within both groups. Results are never dropped, since
+// the author sent by Audiobookshelf is often incomplete or spelled differently.
+func preferAuthor(results []service.AbsBookMetadata, author string) []service.AbsBookMetadata {
+	names := textmatch.SplitNames(author)
+	if len(names) == 0 || len(results) < 2 {
+		return results
+	}
+
+	matched := make([]service.AbsBookMetadata, 0, len(results))
+	var rest []service.AbsBookMetadata
+	for _, r := range results {
+		if textmatch.MatchesAnyName(names, r) {
+			matched = append(matched, r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	return append(matched, rest...)
+}
@@ -6,30 +6,64 @@ import (
 	"strings"
 )
 
-// RJCode represents a DLsite work ID (e.g., RJ123456).
-type RJCode struct {
+// ProductCode represents a DLsite product ID (e.g., RJ123456, VJ012345, BJ123456).
+// The two-letter prefix identifies the product family and thereby the storefronts
+// the product can be sold on.
+type ProductCode struct {
 	value string
 }
 
-var rjCodeRegex = regexp.MustCompile(`(?i)^RJ\d{6,8}$`)
+// productSites lists the storefronts (site sections) each product family is sold on,
+// in the order they are tried when looking a product up.
+var productSites = map[string][]string{
+	"RJ": {"maniax", "home", "girls", "bl"},      // doujin works
+	"VJ": {"pro", "soft", "girls-pro", "bl-pro"}, // commercial games and voice works
+	"BJ": {"books", "comic"},                     // commercial books and comics
+}
+
+// productFamilies lists the product families in the order their works are searched.
+var productFamilies = []string{"RJ", "VJ", "BJ"}
+
+// productSearchCategories maps each product family to the work category that selects
+// its storefronts in DLsite's faceted search.
+var productSearchCategories = map[string]string{
+	"RJ": "doujin",
+	"VJ": "pc",
+	"BJ": "books",
+}
+
+var productCodeRegex = regexp.MustCompile(`(?i)^(?:RJ|VJ|BJ)\d{6,8}$`)
 
-// NewRJCode validates and creates a new RJCode.
-func NewRJCode(code string) (RJCode, error) {
+// NewProductCode validates and creates a new ProductCode.
+func NewProductCode(code string) (ProductCode, error) {
 	code = strings.ToUpper(strings.TrimSpace(code))
-	if !rjCodeRegex.MatchString(code) {
-		return RJCode{}, errors.New("invalid RJ code format")
+	if !productCodeRegex.MatchString(code) {
+		return ProductCode{}, errors.New("invalid product code format")
+	}
+	return ProductCode{value: code}, nil
+}
+
+// String returns the string representation of the ProductCode.
+func (c ProductCode) String() string {
+	return c.value
+}
+
+// Prefix returns the product family prefix, e.g. "RJ".
+func (c ProductCode) Prefix() string {
+	if len(c.value) < 2 {
+		return ""
 	}
-	return RJCode{value: code}, nil
+	return c.value[:2]
 }
 
-// String returns the string representation of the RJCode.
-func (r RJCode) String() string {
-	return r.value
+// Sites returns the storefronts the product may be listed on, most likely first.
+func (c ProductCode) Sites() []string {
+	return productSites[c.Prefix()]
 }
 
 // AsmrWork represents the DLsite-specific entity for an ASMR work.
 type AsmrWork struct {
-	RJCode      RJCode
+	Code        ProductCode
 	Title       string
 	Circle      string
 	CV          []string
@@ -43,4 +77,15 @@ type AsmrWork struct {
 	Scenario    string
 	WorkFormat  string
 	AgeRating   string
+	Languages   []string
+	// OriginalCode is the product the work was translated from; empty for originals.
+	OriginalCode string
+	// Editions are the other language editions of the work, including the original.
+	Editions []Edition
+}
+
+// Edition is a language edition of a DLsite work.
+type Edition struct {
+	Code     string
+	Language string
 }
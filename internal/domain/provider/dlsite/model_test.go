@@ -4,7 +4,7 @@ import (
 	"testing"
 )
 
-func TestNewRJCode_Valid(t *testing.T) {
+func TestNewProductCode_Valid(t *testing.T) {
 	tests := []struct {
 		input string
 		want  string
@@ -13,36 +13,91 @@ func TestNewRJCode_Valid(t *testing.T) {
 		{"rj123456", "RJ123456"},
 		{"RJ12345678", "RJ12345678"},
 		{"  RJ123456  ", "RJ123456"},
+		{"vj012345", "VJ012345"},
+		{"BJ01234567", "BJ01234567"},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.input, func(t *testing.T) {
-			rj, err := NewRJCode(tc.input)
+			code, err := NewProductCode(tc.input)
 			if err != nil {
-				t.Fatalf("NewRJCode(%q) returned error: %v", tc.input, err)
+				t.Fatalf("NewProductCode(%q) returned error: %v", tc.input, err)
 			}
-			if rj.String() != tc.want {
-				t.Errorf("NewRJCode(%q).String() = %q, want %q", tc.input, rj.String(), tc.want)
+			if code.String() != tc.want {
+				t.Errorf("NewProductCode(%q).String() = %q, want %q", tc.input, code.String(), tc.want)
 			}
 		})
 	}
 }
 
-func TestNewRJCode_Invalid(t *testing.T) {
+func TestNewProductCode_Invalid(t *testing.T) {
 	tests := []string{
 		"",
 		"RJ12345",     // too short
 		"RJ123456789", // too long
 		"XX123456",    // wrong prefix
-		"hello",       // not an RJ code
+		"hello",       // not a product code
 		"123456",      // missing prefix
 	}
 
 	for _, input := range tests {
 		t.Run(input, func(t *testing.T) {
-			_, err := NewRJCode(input)
+			_, err := NewProductCode(input)
 			if err == nil {
-				t.Errorf("NewRJCode(%q) expected error, got nil", input)
+				t.Errorf("NewProductCode(%q) expected error, got nil", input)
+			}
+		})
+	}
+}
+
+func TestProductCode_Sites(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"RJ123456", "maniax"},
+		{"VJ012345", "pro"},
+		{"BJ123456", "books"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			code, err := NewProductCode(tc.input)
+			if err != nil {
+				t.Fatalf("NewProductCode(%q) returned error: %v", tc.input, err)
+			}
+			if sites := code.Sites(); len(sites) == 0 || sites[0] != tc.want {
+				t.Errorf("%s.Sites() = %v, want %q first", tc.input, sites, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindProductCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		found bool
+	}{
+		{"RJ123456", "RJ123456", true},
+		{"[RJ01234567] 【耳かき】Title (CV.Name)", "RJ01234567", true},
+		{"rj01234567_title", "RJ01234567", true},
+		{"Title_RJ123456", "RJ123456", true},
+		{"ＲＪ０１２３４５６７　タイトル", "RJ01234567", true},
+		{"(VJ123456) Title", "VJ123456", true},
+		{"bj123456.zip", "BJ123456", true},
+		{"XRJ123456", "", false},     // part of a longer word
+		{"RJ123456789", "", false},   // too many digits
+		{"RJ012345678", "", false},   // not a prefix of a longer code
+		{"RJ12345 Title", "", false}, // too few digits
+		{"Title without code", "", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			code, found := findProductCode(tc.input)
+			if got := code.String(); found != tc.found || got != tc.want {
+				t.Errorf("findProductCode(%q) = (%q, %v), want (%q, %v)", tc.input, got, found, tc.want, tc.found)
 			}
 		})
 	}
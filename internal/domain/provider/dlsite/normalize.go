@@ -0,0 +1,33 @@
+package dlsite
+
+import (
+	"regexp"
+
+	"audiobookshelf-asmr-provider/internal/domain/provider/textmatch"
+)
+
+// embeddedCodeRegex finds a product code anywhere in a string, e.g. in folder names
+// like "[RJ01234567] Title (CV.Name)" or "rj01234567_title". The code must not be
+// preceded by a letter or followed by a digit, so that it is not cut out of a longer word.
+var embeddedCodeRegex = regexp.MustCompile(`(?i)(?:^|[^a-z])((?:RJ|VJ|BJ)\d{6,8})(?:$|\D)`)
+
+// findProductCode returns the first DLsite product code embedded in s.
+// Full-width characters are folded to ASCII before matching.
+func findProductCode(s string) (ProductCode, bool) {
+	m := embeddedCodeRegex.FindStringSubmatch(textmatch.ToHalfWidth(s))
+	if m == nil {
+		return ProductCode{}, false
+	}
+	code, err := NewProductCode(m[1])
+	return code, err == nil
+}
+
+// FindProductID returns the first DLsite product code embedded in s, e.g. in a
+// folder name, in canonical form.
+func (f *dlsiteFetcher) FindProductID(s string) (string, bool) {
+	code, ok := findProductCode(s)
+	if !ok {
+		return "", false
+	}
+	return code.String(), true
+}
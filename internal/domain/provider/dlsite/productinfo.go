@@ -0,0 +1,139 @@
+package dlsite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// productInfo is a product entry of DLsite's product-info (ajax) JSON endpoint.
+// Only the fields used by the provider are decoded.
+type productInfo struct {
+	WorkName    string `json:"work_name"`
+	MakerName   string `json:"maker_name"`
+	RegistDate  string `json:"regist_date"` // "2006-01-02 15:04:05"
+	AgeCategory int    `json:"age_category"`
+	Price       int    `json:"price"`
+	WorkImage   string `json:"work_image"`
+	Options     string `json:"options"` // "#"-separated flags, including the work's languages
+	Genres      []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+	TranslationInfo struct {
+		IsOriginal     bool   `json:"is_original"`
+		OriginalWorkno string `json:"original_workno"`
+		ParentWorkno   string `json:"parent_workno"`
+		Lang           string `json:"lang"` // language flag of a translation, e.g. "ENG"
+	} `json:"translation_info"`
+	LanguageEditions []struct {
+		Workno string `json:"workno"`
+		Lang   string `json:"lang"`
+	} `json:"language_editions"`
+}
+
+// Age categories reported by the product-info endpoint, mapped to the labels
+// shown on the work page.
+var ageCategories = map[int]string{
+	1: "全年齢",
+	2: "R-15",
+	3: "18禁",
+}
+
+// fetchProductInfo fetches the structured product info for code from the given storefront.
+func (f *dlsiteFetcher) fetchProductInfo(ctx context.Context, site string, code ProductCode) (productInfo, error) {
+	infoURL := f.withLocale(fmt.Sprintf("%s/%s/product/info/ajax?product_id=%s", f.baseURL, site, url.QueryEscape(code.String())))
+
+	resp, err := f.get(ctx, infoURL)
+	if err != nil {
+		return productInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	// The response is keyed by product ID. Unknown products yield an empty JSON array,
+	// which fails to decode into the map.
+	var infos map[string]productInfo
+	if err := json.NewDecoder(resp.Body).Decode(&infos); err != nil {
+		return productInfo{}, fmt.Errorf("decoding product info: %w", err)
+	}
+	info, ok := infos[code.String()]
+	if !ok {
+		return productInfo{}, &NotFoundError{URL: infoURL}
+	}
+	return info, nil
+}
+
+// applyTo overwrites the fields of work for which the product info has a value.
+func (p productInfo) applyTo(work *AsmrWork) {
+	if name := strings.TrimSpace(p.WorkName); name != "" {
+		work.Title = name
+	}
+	if maker := strings.TrimSpace(p.MakerName); maker != "" {
+		work.Circle = maker
+	}
+	if date, _, _ := strings.Cut(strings.TrimSpace(p.RegistDate), " "); date != "" {
+		work.ReleaseDate = date
+	}
+	if rating, ok := ageCategories[p.AgeCategory]; ok {
+		work.AgeRating = rating
+	}
+	if p.Price > 0 {
+		work.Price = p.Price
+	}
+	if img := strings.TrimSpace(p.WorkImage); img != "" {
+		if strings.HasPrefix(img, "//") {
+			img = "https:" + img
+		}
+		work.CoverURL = img
+	}
+	if langs := languagesFromOptions(p.Options); len(langs) > 0 {
+		work.Languages = langs
+	}
+	p.applyTranslationTo(work)
+	if len(p.Genres) > 0 {
+		var tags []string
+		for _, g := range p.Genres {
+			if name := strings.TrimSpace(g.Name); name != "" {
+				tags = append(tags, name)
+			}
+		}
+		if len(tags) > 0 {
+			work.Tags = tags
+		}
+	}
+}
+
+// applyTranslationTo links a translated work to its original and lists the
+// work's other language editions.
+func (p productInfo) applyTranslationTo(work *AsmrWork) {
+	ti := p.TranslationInfo
+	if !ti.IsOriginal {
+		original := ti.OriginalWorkno
+		if original == "" {
+			original = ti.ParentWorkno
+		}
+		if code, err := NewProductCode(original); err == nil && code != work.Code {
+			work.OriginalCode = code.String()
+		}
+	}
+
+	// A translation is in its own language first, whatever else its options list.
+	if lang, ok := optionLanguages[ti.Lang]; ok {
+		others := slices.DeleteFunc(work.Languages, func(l string) bool { return l == lang })
+		work.Languages = append([]string{lang}, others...)
+	}
+
+	var editions []Edition
+	for _, e := range p.LanguageEditions {
+		code, err := NewProductCode(e.Workno)
+		if err != nil || code == work.Code {
+			continue
+		}
+		editions = append(editions, Edition{Code: code.String(), Language: optionLanguages[e.Lang]})
+	}
+	if len(editions) > 0 {
+		work.Editions = editions
+	}
+}
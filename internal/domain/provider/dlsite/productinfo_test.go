@@ -0,0 +1,199 @@
+package dlsite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+// newFixtureServer serves the work page fixture and, when info names a fixture
+// file, that file as the product info response.
+func newFixtureServer(t *testing.T, info string) *httptest.Server {
+	t.Helper()
+	page, err := os.ReadFile("testdata/work.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infoJSON []byte
+	if info != "" {
+		if infoJSON, err = os.ReadFile(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/maniax/work/=/product_id/RJ01234567.html":
+			_, _ = w.Write(page)
+		case productInfoPath:
+			if r.URL.Query().Get("product_id") != "RJ01234567" {
+				t.Errorf("unexpected product_id %q", r.URL.Query().Get("product_id"))
+			}
+			if infoJSON == nil {
+				// Unknown products are answered with an empty array.
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(infoJSON)
+		default:
+			t.Errorf("unexpected request path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestDLsiteFetcher_GetWork_ProductInfo(t *testing.T) {
+	server := newFixtureServer(t, "testdata/product_info.json")
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+	code, _ := NewProductCode("RJ01234567")
+
+	work, err := f.getWorkByID(context.Background(), code)
+	if err != nil {
+		t.Fatalf("getWorkByID failed: %v", err)
+	}
+
+	// Core fields come from the JSON.
+	if work.Title != "JSON Title" || work.Circle != "JSON Circle" {
+		t.Errorf("expected title and circle from JSON, got %q / %q", work.Title, work.Circle)
+	}
+	if work.ReleaseDate != "2024-03-15" || work.AgeRating != "18禁" || work.Price != 1320 {
+		t.Errorf("unexpected date/rating/price: %q %q %d", work.ReleaseDate, work.AgeRating, work.Price)
+	}
+	if want := "https://img.dlsite.jp/modpub/images2/work/doujin/RJ01235000/RJ01234567_img_main.jpg"; work.CoverURL != want {
+		t.Errorf("expected cover %q, got %q", want, work.CoverURL)
+	}
+	if want := []string{"ASMR", "Binaural"}; !reflect.DeepEqual(work.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, work.Tags)
+	}
+	if want := []string{"Japanese"}; !reflect.DeepEqual(work.Languages, want) {
+		t.Errorf("expected languages %v, got %v", want, work.Languages)
+	}
+
+	// Fields the JSON lacks are scraped.
+	if work.Description != "HTML description" || work.Scenario != "Writer" || work.WorkFormat != "ボイス・ASMR" {
+		t.Errorf("expected scraped description/scenario/format, got %q %q %q", work.Description, work.Scenario, work.WorkFormat)
+	}
+	if want := []string{"Actor1", "Actor2"}; !reflect.DeepEqual(work.CV, want) {
+		t.Errorf("expected voice actors %v, got %v", want, work.CV)
+	}
+}
+
+func TestDLsiteFetcher_GetWork_HTMLFallback(t *testing.T) {
+	server := newFixtureServer(t, "")
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+	code, _ := NewProductCode("RJ01234567")
+
+	work, err := f.getWorkByID(context.Background(), code)
+	if err != nil {
+		t.Fatalf("getWorkByID failed: %v", err)
+	}
+
+	if work.Title != "HTML Title" || work.Circle != "HTML Circle" {
+		t.Errorf("expected title and circle from HTML, got %q / %q", work.Title, work.Circle)
+	}
+	if work.ReleaseDate != "2024-03-01" || work.AgeRating != "全年齢" || work.Price != 990 {
+		t.Errorf("unexpected date/rating/price: %q %q %d", work.ReleaseDate, work.AgeRating, work.Price)
+	}
+	if work.CoverURL != "https://example.com/html_cover.jpg" {
+		t.Errorf("expected cover from HTML, got %q", work.CoverURL)
+	}
+	if want := []string{"HTML Tag"}; !reflect.DeepEqual(work.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, work.Tags)
+	}
+}
+
+// translationInfos are product info responses for a Japanese original and its
+// official English translation.
+var translationInfos = map[string]string{
+	"RJ01234567": `{"RJ01234567": {
+		"work_name": "原作", "options": "JPN",
+		"translation_info": {"is_original": true},
+		"language_editions": [
+			{"workno": "RJ01234567", "lang": "JPN"},
+			{"workno": "RJ01300000", "lang": "ENG"}
+		]}}`,
+	"RJ01300000": `{"RJ01300000": {
+		"work_name": "Translation", "options": "JPN#ENG",
+		"translation_info": {"is_original": false, "original_workno": "RJ01234567", "parent_workno": "RJ01234567", "lang": "ENG"},
+		"language_editions": [
+			{"workno": "RJ01234567", "lang": "JPN"},
+			{"workno": "RJ01300000", "lang": "ENG"}
+		]}}`,
+}
+
+func newTranslationServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == productInfoPath {
+			info, ok := translationInfos[r.URL.Query().Get("product_id")]
+			if !ok {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			_, _ = w.Write([]byte(info))
+			return
+		}
+		_, _ = w.Write([]byte(`<html><body></body></html>`))
+	}))
+}
+
+func TestDLsiteFetcher_GetWork_Translation(t *testing.T) {
+	server := newTranslationServer(t)
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+
+	detail, err := f.GetWork(context.Background(), "RJ01300000")
+	if err != nil {
+		t.Fatalf("GetWork failed: %v", err)
+	}
+	if detail.OriginalID != "RJ01234567" {
+		t.Errorf("expected original RJ01234567, got %q", detail.OriginalID)
+	}
+	if want := []service.Edition{{ID: "RJ01234567", Language: "Japanese"}}; !reflect.DeepEqual(detail.Editions, want) {
+		t.Errorf("expected editions %v, got %v", want, detail.Editions)
+	}
+	if detail.Language != "English" {
+		t.Errorf("expected a translation to be in its own language, got %q", detail.Language)
+	}
+}
+
+func TestDLsiteFetcher_Search_PreferEdition(t *testing.T) {
+	server := newTranslationServer(t)
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+
+	tests := []struct {
+		name  string
+		query service.Query
+		want  string
+	}{
+		{"original to translation", service.Query{Text: "RJ01234567", Language: "en"}, "RJ01300000"},
+		{"translation to original", service.Query{Text: "RJ01300000", Language: "Japanese"}, "RJ01234567"},
+		{"already in language", service.Query{Text: "RJ01300000", Language: "ENG"}, "RJ01300000"},
+		{"no such edition", service.Query{Text: "RJ01234567", Language: "ko"}, "RJ01234567"},
+		{"no preference", service.Query{Text: "RJ01234567"}, "RJ01234567"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := f.Search(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(results) != 1 || results[0].ISBN != tt.want {
+				t.Errorf("expected %s, got %+v", tt.want, results)
+			}
+		})
+	}
+}
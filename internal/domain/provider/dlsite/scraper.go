@@ -2,16 +2,22 @@ package dlsite
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
 
+	"audiobookshelf-asmr-provider/internal/domain/provider/transport"
 	"audiobookshelf-asmr-provider/internal/service"
 )
 
@@ -19,25 +25,94 @@ type dlsiteFetcher struct {
 	client           *http.Client
 	baseURL          string
 	ageCheckDisabled bool
+	// locale is the preferred locale for titles, descriptions and tags (e.g. "en_US").
+	locale string
+
+	// enrichWorkers bounds the number of work pages fetched concurrently to enrich
+	// keyword search results, and enrichTimeout bounds each of those fetches.
+	// searchBudget bounds a whole keyword search, enrichment included, and
+	// enrichLimit is the number of leading results enriched within it.
+	enrichWorkers int
+	enrichTimeout time.Duration
+	searchBudget  time.Duration
+	enrichLimit   int
+
+	// parser validates the parsed work pages to detect markup changes.
+	parser parserMonitor
 }
 
+const (
+	// defaultSearchLimit is the number of keyword search results returned per page
+	// unless the query asks for a different limit.
+	defaultSearchLimit = 5
+	// searchPageSize is the number of works requested per DLsite search results page.
+	searchPageSize = 30
+
+	defaultEnrichWorkers = 3
+	defaultEnrichTimeout = 8 * time.Second
+	// defaultSearchBudget keeps keyword searches well within the server's 15s write
+	// timeout. Searches are fetched on a context without the request's deadline, so
+	// the budget is the only bound on how long enrichment may take.
+	defaultSearchBudget = 10 * time.Second
+	// requestsPerWork is the number of upstream requests enriching one result takes:
+	// its work page and its product info.
+	requestsPerWork = 2
+
+	// maxErrorPageBytes bounds how much of an error page is read to classify it.
+	maxErrorPageBytes = 256 << 10
+)
+
 // NewDLsiteFetcher creates a new instance of the DLsite provider.
 func NewDLsiteFetcher() service.Provider {
+	return NewDLsiteFetcherWithTransport(nil)
+}
+
+// NewDLsiteFetcherWithTransport creates a new instance of the DLsite provider that
+// sends its requests through rt, or http.DefaultTransport if rt is nil.
+func NewDLsiteFetcherWithTransport(rt http.RoundTripper) service.Provider {
 	disableAgeCheck := false
 	ageCheckEnv := strings.ToLower(os.Getenv("DISABLE_AGE_CHECK"))
 	if ageCheckEnv == "1" || ageCheckEnv == "true" || ageCheckEnv == "yes" {
 		disableAgeCheck = true
 	}
 
+	locale := defaultLocale
+	if env := os.Getenv("DLSITE_LOCALE"); env != "" {
+		if l, ok := normalizeLocale(env); ok {
+			locale = l
+		} else {
+			slog.Warn("Unsupported DLSITE_LOCALE, using default", "value", env, "default", defaultLocale)
+		}
+	}
+
 	return &dlsiteFetcher{
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			// Transient failures (429, 5xx gateway errors, dropped connections) are retried
+			// with backoff; the timeout bounds all attempts together.
+			Transport: transport.NewRetry(rt),
+			Timeout:   30 * time.Second,
 		},
 		baseURL:          "https://www.dlsite.com",
 		ageCheckDisabled: disableAgeCheck,
+		locale:           locale,
+		enrichWorkers:    defaultEnrichWorkers,
+		enrichTimeout:    defaultEnrichTimeout,
+		searchBudget:     defaultSearchBudget,
+		enrichLimit:      enrichLimit(rt, defaultSearchBudget),
 	}
 }
 
+// enrichLimit returns how many search results can be enriched within budget at the
+// request rate rt allows. Transports that do not report a rate are not limited, and
+// every result is enriched.
+func enrichLimit(rt http.RoundTripper, budget time.Duration) int {
+	r, ok := rt.(interface{ Rate() float64 })
+	if !ok {
+		return service.MaxSearchLimit
+	}
+	return max(int(r.Rate()*budget.Seconds())/requestsPerWork, 1)
+}
+
 // ID returns the unique identifier for this provider.
 func (f *dlsiteFetcher) ID() string {
 	return "dlsite"
@@ -48,73 +123,298 @@ func (f *dlsiteFetcher) CacheTTL() time.Duration {
 	return 24 * time.Hour
 }
 
-// Search searches for works matching the query. Currently only supports RJ codes.
-func (f *dlsiteFetcher) Search(ctx context.Context, query string) ([]service.AbsBookMetadata, error) {
-	if rj, err := NewRJCode(query); err == nil {
-		work, err := f.getWorkByID(ctx, rj)
+// Describe reports the provider's capabilities. Adult content is only
+// available when the age check is disabled.
+func (f *dlsiteFetcher) Describe() service.ProviderInfo {
+	return service.ProviderInfo{
+		Name:       "DLsite",
+		QueryKinds: []service.QueryKind{service.QueryKindID, service.QueryKindKeyword},
+		Filters: []service.Filter{
+			service.FilterAge,
+			service.FilterVoiceActor,
+			service.FilterMaker,
+			service.FilterGenre,
+			service.FilterReleasedAfter,
+			service.FilterReleasedBefore,
+		},
+		AdultContent: f.ageCheckDisabled,
+	}
+}
+
+// ParserStats reports how many of the parsed work pages lacked mandatory fields.
+// The parser is degraded after several such pages in a row.
+func (f *dlsiteFetcher) ParserStats() service.ParserStats {
+	return f.parser.snapshot()
+}
+
+// Search searches for works matching the query. A product code found anywhere in
+// the query (e.g. a folder name like "[RJ01234567] Title") is looked up directly,
+// switching to the edition in the query's language if the work has one;
+// anything else is a keyword search narrowed by the query's filters, in which works
+// by a circle or voice actor matching the query's author are listed first.
+func (f *dlsiteFetcher) Search(ctx context.Context, query service.Query) ([]service.AbsBookMetadata, error) {
+	term := query.Term()
+	if code, ok := findProductCode(term); ok {
+		// A code matches a single work, so there is nothing beyond the first page.
+		if query.PageNumber() > 1 {
+			return []service.AbsBookMetadata{}, nil
+		}
+		work, err := f.getWorkByID(ctx, code)
 		if err != nil {
 			return nil, err
 		}
+		if query.Language != "" {
+			work = f.preferEdition(ctx, work, query.Language)
+		}
 		return []service.AbsBookMetadata{f.toAbsMetadata(work)}, nil
 	}
 	// Keyword search implementation
-	return f.searchKeywords(ctx, query)
+	results, err := f.searchKeywords(ctx, term, query.Filters, query.LimitOr(defaultSearchLimit), query.PageNumber())
+	if err != nil {
+		return nil, err
+	}
+	return preferAuthor(results, query.Author), nil
 }
 
-func (f *dlsiteFetcher) searchKeywords(ctx context.Context, query string) ([]service.AbsBookMetadata, error) {
-	searchURL := fmt.Sprintf("%s/maniax/fsr/=/keyword/%s", f.baseURL, url.QueryEscape(query))
+// GetWork looks up a single work by its product code and returns all scraped fields.
+func (f *dlsiteFetcher) GetWork(ctx context.Context, id string) (*service.WorkDetail, error) {
+	code, err := NewProductCode(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is not a DLsite work ID", service.ErrNotFound, id)
+	}
 
-	doc, err := f.fetchPage(ctx, searchURL)
+	work, err := f.getWorkByID(ctx, code)
 	if err != nil {
 		return nil, err
 	}
+	detail := f.toWorkDetail(work)
+	return &detail, nil
+}
+
+// preferEdition returns the edition of work in the given language. The work itself
+// is returned if it already is in that language, has no such edition, or the
+// edition cannot be fetched.
+func (f *dlsiteFetcher) preferEdition(ctx context.Context, work AsmrWork, language string) AsmrWork {
+	want, ok := parseLanguage(language)
+	if !ok || (len(work.Languages) > 0 && work.Languages[0] == want) {
+		return work
+	}
+
+	for _, e := range work.Editions {
+		if e.Language != want {
+			continue
+		}
+		code, err := NewProductCode(e.Code)
+		if err != nil {
+			break
+		}
+		edition, err := f.getWorkByID(ctx, code)
+		if err != nil {
+			slog.Debug("Failed to fetch DLsite language edition", "code", e.Code, "error", err)
+			break
+		}
+		return edition
+	}
+	return work
+}
+
+// searchKeywords returns the given page of keyword search results, limit results per page.
+// DLsite's own result pages are followed as far as needed to fill the page.
+func (f *dlsiteFetcher) searchKeywords(ctx context.Context, query string, filters service.Filters, limit, page int) ([]service.AbsBookMetadata, error) {
+	deadline := time.Now().Add(f.searchBudget)
+	offset := (page - 1) * limit
+	sitePage := offset/searchPageSize + 1
+	skip := offset % searchPageSize
 
 	var results []service.AbsBookMetadata
-	extractor := regexp.MustCompile(`(?i)RJ\d{6,8}`)
+	for len(results) < limit {
+		doc, err := f.fetchPage(ctx, f.searchURL(query, filters, sitePage))
+		if err != nil {
+			// Pages past the end do not exist; any other failure after the first
+			// page still leaves us with results to return, unless DLsite started
+			// serving interstitials, whose results must not be cached.
+			var (
+				notFound     *NotFoundError
+				interstitial *InterstitialError
+			)
+			if !errors.As(err, &interstitial) && (len(results) > 0 || (sitePage > 1 && errors.As(err, &notFound))) {
+				slog.Debug("Stopping DLsite search pagination", "page", sitePage, "error", err)
+				break
+			}
+			return nil, err
+		}
 
-	// Try table format first (classic)
-	doc.Find("#search_result_list tr").EachWithBreak(func(i int, s *goquery.Selection) bool {
-		if len(results) >= 5 {
-			return false
+		found := f.extractSearchResults(doc)
+		if skip < len(found) {
+			results = append(results, found[skip:]...)
+		}
+		skip = 0
+
+		// A short page is the last one.
+		if len(found) < searchPageSize {
+			break
 		}
+		sitePage++
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	if err := f.enrich(ctx, results, deadline); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// searchURL builds the URL of a keyword search results page. DLsite's faceted
+// search takes its parameters as "/name/value" path segments. Although the search
+// lives on the doujin storefront, it is asked for the work categories of every
+// product family, so that commercial (VJ) and book (BJ) works are found as well.
+func (f *dlsiteFetcher) searchURL(query string, filters service.Filters, page int) string {
+	var b strings.Builder
+	b.WriteString(f.baseURL + "/maniax/fsr/=")
+
+	add := func(name, value string) {
+		b.WriteString("/" + name + "/" + url.QueryEscape(value))
+	}
+
+	// Genres are selected by their numeric ID; a genre name can only be searched for.
+	genreID := ""
+	if _, err := strconv.Atoi(filters.Genre); err == nil {
+		genreID = filters.Genre
+	} else if filters.Genre != "" {
+		query = strings.TrimSpace(query + " " + filters.Genre)
+	}
+
+	if query != "" {
+		add("keyword", query)
+	}
+	for i, family := range productFamilies {
+		add(fmt.Sprintf("work_category%%5B%d%%5D", i), productSearchCategories[family])
+	}
+	if category, ok := ageCategoryParams[filters.Age]; ok {
+		add("age_category%5B0%5D", category)
+	}
+	if filters.Maker != "" {
+		add("keyword_maker_name", filters.Maker)
+	}
+	if filters.VoiceActor != "" {
+		add("keyword_creater", filters.VoiceActor)
+	}
+	if genreID != "" {
+		add("genre%5B0%5D", genreID)
+	}
+	if filters.ReleasedAfter != "" {
+		add("regist_date_start", filters.ReleasedAfter)
+	}
+	if filters.ReleasedBefore != "" {
+		add("regist_date_end", filters.ReleasedBefore)
+	}
+	// The pagination in searchKeywords relies on the page size, so it is requested
+	// explicitly rather than left to DLsite's default.
+	add("per_page", strconv.Itoa(searchPageSize))
+	if page > 1 {
+		add("page", strconv.Itoa(page))
+	}
+	return f.withLocale(b.String())
+}
+
+// ageCategoryParams maps service.Filters.Age to DLsite's age category search values.
+var ageCategoryParams = map[string]string{
+	service.AgeAll: "general",
+	service.AgeR15: "r15",
+	service.AgeR18: "adult",
+}
+
+// extractSearchResults extracts the partial metadata of every work on a search results page.
+func (f *dlsiteFetcher) extractSearchResults(doc *goquery.Document) []service.AbsBookMetadata {
+	var results []service.AbsBookMetadata
+	extractor := regexp.MustCompile(`(?i)(?:RJ|VJ|BJ)\d{6,8}`)
+
+	// Try table format first (classic)
+	doc.Find("#search_result_list tr").Each(func(i int, s *goquery.Selection) {
 		if meta, ok := f.extractFromTable(s, extractor); ok {
 			results = append(results, meta)
 		}
-		return true
 	})
 
 	// If no results from table, try grid format (n_worklist)
 	if len(results) == 0 {
-		doc.Find(".n_worklist li").EachWithBreak(func(i int, s *goquery.Selection) bool {
-			if len(results) >= 5 {
-				return false
-			}
+		doc.Find(".n_worklist li").Each(func(i int, s *goquery.Selection) {
 			if meta, ok := f.extractFromGrid(s, extractor); ok {
 				results = append(results, meta)
 			}
-			return true
 		})
 	}
 
-	// Enhance results with full metadata
-	for i, res := range results {
-		if res.ISBN == "" {
-			continue
-		}
-		rjCode, err := NewRJCode(res.ISBN)
+	return results
+}
+
+// enrich replaces search results with the full metadata of their work pages.
+// Pages are fetched by a bounded pool of workers, each fetch with its own deadline
+// derived from ctx, and all of them are abandoned at deadline. Results whose page
+// cannot be fetched in time keep the partial metadata from the search page. If DLsite
+// serves an interstitial for any work page, its error is returned, so that the
+// partial results are not cached. Only the first enrichLimit results are enriched;
+// the rest could not be fetched within the budget at the upstream rate limit, and
+// keep their partial metadata.
+func (f *dlsiteFetcher) enrich(ctx context.Context, results []service.AbsBookMetadata, deadline time.Time) error {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, max(f.enrichWorkers, 1))
+
+		mu           sync.Mutex
+		interstitial error
+	)
+
+	for i, res := range results[:min(len(results), f.enrichLimit)] {
+		code, err := NewProductCode(res.ISBN)
 		if err != nil {
 			continue
 		}
 
-		// Fetch full details
-		work, err := f.getWorkByID(ctx, rjCode)
-		if err == nil {
-			results[i] = f.toAbsMetadata(work)
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// The caller has gone away or the budget is spent; keep what we have.
+			slog.Debug("Stopping DLsite search enrichment", "enriched", i, "results", len(results), "error", ctx.Err())
+			wg.Wait()
+			return interstitial
 		}
-		// If error, keep the partial result from search page
+
+		wg.Add(1)
+		go func(i int, code ProductCode) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			itemCtx, cancel := context.WithTimeout(ctx, f.enrichTimeout)
+			defer cancel()
+
+			work, err := f.getWorkByID(itemCtx, code)
+			var ie *InterstitialError
+			if errors.As(err, &ie) {
+				mu.Lock()
+				if interstitial == nil {
+					interstitial = err
+				}
+				mu.Unlock()
+				return
+			}
+			if err != nil {
+				slog.Debug("Keeping partial DLsite search result", "code", code.String(), "error", err)
+				return
+			}
+			// Each worker writes a distinct index, so no locking is needed.
+			results[i] = f.toAbsMetadata(work)
+		}(i, code)
 	}
 
-	return results, nil
+	wg.Wait()
+	return interstitial
 }
 
 func (f *dlsiteFetcher) extractFromTable(s *goquery.Selection, extractor *regexp.Regexp) (service.AbsBookMetadata, bool) {
@@ -156,7 +456,6 @@ func (f *dlsiteFetcher) extractFromTable(s *goquery.Selection, extractor *regexp
 		ISBN:      rjCode,
 		Publisher: "DLsite",
 		Explicit:  true,
-		Language:  "Japanese",
 		Cover:     coverURL,
 	}, true
 }
@@ -176,12 +475,8 @@ func (f *dlsiteFetcher) extractFromGrid(s *goquery.Selection, extractor *regexp.
 	} else {
 		parts := strings.Split(link, "/")
 		for _, p := range parts {
-			if strings.HasPrefix(strings.ToUpper(p), "RJ") {
-				if strings.HasSuffix(p, ".html") {
-					rjCode = strings.TrimSuffix(p, ".html")
-				} else {
-					rjCode = p
-				}
+			if code, err := NewProductCode(strings.TrimSuffix(p, ".html")); err == nil {
+				rjCode = code.String()
 				break
 			}
 		}
@@ -210,7 +505,6 @@ func (f *dlsiteFetcher) extractFromGrid(s *goquery.Selection, extractor *regexp.
 		ISBN:      rjCode,
 		Publisher: "DLsite",
 		Explicit:  true,
-		Language:  "Japanese",
 		Cover:     coverURL,
 	}, true
 }
@@ -236,31 +530,99 @@ func (f *dlsiteFetcher) extractMakerAndNarrator(s *goquery.Selection) (string, s
 	return maker, narrator
 }
 
-// getWorkByID fetches and parses the work page for a given RJ code.
-func (f *dlsiteFetcher) getWorkByID(ctx context.Context, code RJCode) (AsmrWork, error) {
-	targetURL := fmt.Sprintf("%s/maniax/work/=/product_id/%s.html", f.baseURL, code.String())
+// productInfoResult carries the outcome of a product info fetch made alongside
+// the work page.
+type productInfoResult struct {
+	info productInfo
+	err  error
+}
 
-	doc, err := f.fetchPage(ctx, targetURL)
+// getWorkByID fetches and parses the work page for a given product code.
+// The storefronts the product family is sold on are tried in turn until one of
+// them has a page for it. The product info of the first storefront is fetched
+// alongside the page, so that the two requests do not add up.
+func (f *dlsiteFetcher) getWorkByID(ctx context.Context, code ProductCode) (AsmrWork, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sites := code.Sites()
+	infoCh := make(chan productInfoResult, 1)
+	go func() {
+		info, err := f.fetchProductInfo(ctx, sites[0], code)
+		infoCh <- productInfoResult{info, err}
+	}()
+
+	var (
+		doc       *goquery.Document
+		site      string
+		targetURL string
+		err       error
+	)
+	for _, site = range sites {
+		targetURL = f.withLocale(fmt.Sprintf("%s/%s/work/=/product_id/%s.html", f.baseURL, site, code.String()))
+		doc, err = f.fetchPage(ctx, targetURL)
+		var notFound *NotFoundError
+		if !errors.As(err, &notFound) {
+			break
+		}
+	}
 	if err != nil {
 		return AsmrWork{}, err
 	}
 
 	work := AsmrWork{
-		RJCode:      code,
+		Code:        code,
 		DLsiteURL:   targetURL,
 		Title:       f.extractTitle(doc),
 		Circle:      f.extractCircle(doc),
 		CoverURL:    f.extractCoverURL(doc),
 		Description: f.extractDescription(doc), // Added description extraction
+		Price:       f.extractPrice(doc),
 	}
 
-	// Fetch all table data (voice actors, genres, series, scenario, format, age rating) at once
+	// Fetch all table data (voice actors, genres, series, scenario, format, age rating, languages) at once
 	f.extractTableData(doc, &work)
+	f.parser.record(code, validateWorkPage(doc, work))
+
+	// Prefer the structured product info for the core fields; the page is only
+	// authoritative for fields the JSON does not carry (description, voice actors, ...).
+	var info productInfo
+	if site == sites[0] {
+		res := <-infoCh
+		info, err = res.info, res.err
+	} else {
+		info, err = f.fetchProductInfo(ctx, site, code)
+	}
+	if err != nil {
+		slog.Debug("DLsite product info unavailable, using scraped fields", "code", code.String(), "error", err)
+	} else {
+		info.applyTo(&work)
+	}
 
 	return work, nil
 }
 
 func (f *dlsiteFetcher) fetchPage(ctx context.Context, url string) (*goquery.Document, error) {
+	resp, err := f.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if kind := detectInterstitial(resp.StatusCode, resp.Request.URL, doc); kind != nil {
+		return nil, &InterstitialError{Err: kind, URL: url}
+	}
+	return doc, nil
+}
+
+// get performs a GET request against DLsite and returns the response if its status is 200.
+// Interstitial pages that can be recognised without the body of a successful
+// response are reported as an InterstitialError. The caller must close the response body.
+func (f *dlsiteFetcher) get(ctx context.Context, url string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -274,13 +636,27 @@ func (f *dlsiteFetcher) fetchPage(ctx context.Context, url string) (*goquery.Doc
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, &NotFoundError{URL: url}
+	}
 	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		// Error pages may be interstitials too, e.g. a maintenance notice served with 503.
+		doc, _ := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxErrorPageBytes))
+		if kind := detectInterstitial(resp.StatusCode, resp.Request.URL, doc); kind != nil {
+			return nil, &InterstitialError{Err: kind, URL: url}
+		}
 		return nil, fmt.Errorf("dlsite returned status: %d", resp.StatusCode)
 	}
+	// Redirects to the login page are recognisable from the URL alone.
+	if kind := detectInterstitial(resp.StatusCode, resp.Request.URL, nil); kind != nil {
+		resp.Body.Close()
+		return nil, &InterstitialError{Err: kind, URL: url}
+	}
 
-	return goquery.NewDocumentFromReader(resp.Body)
+	return resp, nil
 }
 
 func (f *dlsiteFetcher) extractTitle(doc *goquery.Document) string {
@@ -312,6 +688,19 @@ func (f *dlsiteFetcher) extractDescription(doc *goquery.Document) string {
 	return strings.TrimSpace(tmpDoc.Text())
 }
 
+// extractPrice reads the current price in yen from the purchase box. Returns 0 if not found.
+func (f *dlsiteFetcher) extractPrice(doc *goquery.Document) int {
+	text := doc.Find(".work_buy_content .price").First().Text()
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, text)
+	price, _ := strconv.Atoi(digits)
+	return price
+}
+
 func (f *dlsiteFetcher) extractCircle(doc *goquery.Document) string {
 	return strings.TrimSpace(doc.Find("span.maker_name a").Text())
 }
@@ -336,27 +725,37 @@ func (f *dlsiteFetcher) extractCoverURL(doc *goquery.Document) string {
 	return ""
 }
 
-// extractTableData maps from table information to each field
+// extractTableData maps from table information to each field.
+// Header names differ between locales and slightly between storefronts (e.g. books
+// list "著者" instead of a scenario writer, and some commercial floors use "発売日"
+// for the release date), so each field is matched against all known labels.
 func (f *dlsiteFetcher) extractTableData(doc *goquery.Document, work *AsmrWork) {
+	var bookAuthor string
+
 	doc.Find("#work_outline tr").Each(func(i int, s *goquery.Selection) {
 		header := strings.TrimSpace(s.Find("th").Text())
 		data := s.Find("td")
 
-		if strings.Contains(header, "声優") {
+		if containsAny(header, voiceActorHeaders) {
 			data.Find("a").Each(func(_ int, a *goquery.Selection) {
 				work.CV = append(work.CV, strings.TrimSpace(a.Text()))
 			})
-		} else if strings.Contains(header, "ジャンル") {
+		} else if containsAny(header, genreHeaders) {
 			data.Find("a").Each(func(_ int, a *goquery.Selection) {
 				work.Tags = append(work.Tags, strings.TrimSpace(a.Text()))
 			})
-		} else if strings.Contains(header, "販売日") {
+		} else if containsAny(header, releaseDateHeaders) {
 			dateStr := strings.TrimSpace(data.Find("a").Text())
-			// Format the date string
-			dateStr = strings.ReplaceAll(dateStr, "年", "-")
-			dateStr = strings.ReplaceAll(dateStr, "月", "-")
-			dateStr = strings.ReplaceAll(dateStr, "日", "")
-			work.ReleaseDate = dateStr
+			if dateStr == "" {
+				dateStr = strings.TrimSpace(data.Text())
+			}
+			work.ReleaseDate = normalizeDate(dateStr)
+		} else if containsAny(header, languageHeaders) {
+			data.Find("a").Each(func(_ int, a *goquery.Selection) {
+				if lang, ok := pageLanguages[strings.TrimSpace(a.Text())]; ok {
+					work.Languages = append(work.Languages, lang)
+				}
+			})
 		} else {
 			// Helper function: Get text within td. If there is a link, prioritize that text.
 			getText := func(d *goquery.Selection) string {
@@ -366,23 +765,36 @@ func (f *dlsiteFetcher) extractTableData(doc *goquery.Document, work *AsmrWork)
 				return strings.TrimSpace(d.Text())
 			}
 
-			if strings.Contains(header, "シリーズ") {
+			if containsAny(header, seriesHeaders) {
 				work.Series = getText(data)
-			} else if strings.Contains(header, "シナリオ") {
+			} else if containsAny(header, scenarioHeaders) {
 				work.Scenario = getText(data)
-			} else if strings.Contains(header, "作品形式") {
+			} else if containsAny(header, authorHeaders) {
+				bookAuthor = getText(data)
+			} else if containsAny(header, workFormatHeaders) {
 				work.WorkFormat = getText(data)
-			} else if strings.Contains(header, "年齢指定") {
+			} else if containsAny(header, ageRatingHeaders) {
 				work.AgeRating = getText(data)
 			}
 		}
 	})
+
+	if work.Scenario == "" {
+		work.Scenario = bookAuthor
+	}
 }
 
 // toAbsMetadata: Logic to convert AsmrWork to AbsBookMetadata
 func (f *dlsiteFetcher) toAbsMetadata(work AsmrWork) service.AbsBookMetadata {
 	// Explicit determination: true if "All Ages" (全年齢) is not included in age rating (e.g., R18)
-	isExplicit := !strings.Contains(work.AgeRating, "全年齢")
+	isExplicit := !isAllAges(work.AgeRating)
+
+	// Language: the work's primary language. Works without language information
+	// are Japanese, DLsite's original language.
+	language := "Japanese"
+	if len(work.Languages) > 0 {
+		language = work.Languages[0]
+	}
 
 	// Author: Default is "Scenario". If empty, uses "Circle Name" as fallback.
 	author := work.Scenario
@@ -422,8 +834,33 @@ func (f *dlsiteFetcher) toAbsMetadata(work AsmrWork) service.AbsBookMetadata {
 		Genres:        genres,
 		Tags:          work.Tags,
 		Cover:         work.CoverURL,
-		ISBN:          work.RJCode.String(),
+		ISBN:          work.Code.String(),
 		Explicit:      isExplicit,
-		Language:      "Japanese",
+		Language:      language,
+	}
+}
+
+// toWorkDetail converts AsmrWork to a WorkDetail, keeping the DLsite-specific fields.
+func (f *dlsiteFetcher) toWorkDetail(work AsmrWork) service.WorkDetail {
+	var editions []service.Edition
+	for _, e := range work.Editions {
+		editions = append(editions, service.Edition{ID: e.Code, Language: e.Language})
+	}
+
+	return service.WorkDetail{
+		AbsBookMetadata: f.toAbsMetadata(work),
+		ID:              work.Code.String(),
+		Provider:        f.ID(),
+		URL:             work.DLsiteURL,
+		Circle:          work.Circle,
+		Scenario:        work.Scenario,
+		VoiceActors:     work.CV,
+		ReleaseDate:     work.ReleaseDate,
+		Price:           work.Price,
+		AgeRating:       work.AgeRating,
+		WorkFormat:      work.WorkFormat,
+		Languages:       work.Languages,
+		OriginalID:      work.OriginalCode,
+		Editions:        editions,
 	}
 }
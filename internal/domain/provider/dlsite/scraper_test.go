@@ -2,20 +2,31 @@ package dlsite
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"audiobookshelf-asmr-provider/internal/domain/provider/transport"
 	"audiobookshelf-asmr-provider/internal/service"
 )
 
+// productInfoPath is the product-info endpoint of the maniax storefront.
+const productInfoPath = "/maniax/product/info/ajax"
+
 // newTestFetcher creates a dlsiteFetcher pointing at a test server URL.
 func newTestFetcher(baseURL string) *dlsiteFetcher {
 	f := NewDLsiteFetcher().(*dlsiteFetcher)
 	f.baseURL = baseURL
 	f.ageCheckDisabled = true // Default to true for existing tests
+	// Keep retries of failing responses fast.
+	f.client.Transport = transport.NewRetry(nil, transport.WithBackoff(time.Millisecond, time.Millisecond))
 	return f
 }
 
@@ -75,6 +86,11 @@ func TestDLsiteFetcher_Search_RJCode(t *testing.T) {
     `
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == productInfoPath {
+			// No product info: every field comes from the page.
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
 		if r.URL.Path != "/maniax/work/=/product_id/RJ010101.html" {
 			t.Errorf("Expected path /maniax/work/=/product_id/RJ010101.html, got %s", r.URL.Path)
 		}
@@ -88,7 +104,7 @@ func TestDLsiteFetcher_Search_RJCode(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	results, err := f.Search(ctx, "RJ010101")
+	results, err := f.Search(ctx, service.Query{Text: "RJ010101"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -134,6 +150,84 @@ func assertDetails(t *testing.T, result service.AbsBookMetadata) {
 	}
 }
 
+func TestDLsiteFetcher_Search_EmbeddedCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == productInfoPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Path != "/maniax/work/=/product_id/RJ01234567.html" {
+			t.Errorf("Unexpected request path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`<html><body><h1 id="work_name">Embedded</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+
+	results, err := f.Search(context.Background(), service.Query{Text: "[rj01234567]【耳かき】Title (CV.Name)"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ISBN != "RJ01234567" {
+		t.Errorf("expected direct lookup of RJ01234567, got %+v", results)
+	}
+}
+
+func TestDLsiteFetcher_Search_OtherStorefront(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path != "/comic/work/=/product_id/BJ123456.html" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`<html><body>
+			<h1 id="work_name">Comic Work</h1>
+			<span class="maker_name"><a href="#">Publisher</a></span>
+			<table id="work_outline">
+				<tr><th>著者</th><td><a href="#">Book Author</a></td></tr>
+				<tr><th>発売日</th><td>2024年01月02日</td></tr>
+				<tr><th>年齢指定</th><td><a href="#">全年齢</a></td></tr>
+			</table>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+
+	results, err := f.Search(context.Background(), service.Query{Text: "BJ123456"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	// The product info of the first storefront is requested alongside its page,
+	// so the order of the requests is not fixed.
+	want := []string{
+		"/books/product/info/ajax",
+		"/books/work/=/product_id/BJ123456.html",
+		"/comic/product/info/ajax",
+		"/comic/work/=/product_id/BJ123456.html",
+	}
+	slices.Sort(paths)
+	if !slices.Equal(paths, want) {
+		t.Errorf("expected requests %v, got %v", want, paths)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	r := results[0]
+	if r.Title != "Comic Work" || r.Author != "Book Author" || r.Publisher != "Publisher" || r.PublishedYear != "2024" || r.Explicit {
+		t.Errorf("unexpected metadata: %+v", r)
+	}
+}
+
 func TestDLsiteFetcher_Search_NotFound(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -142,13 +236,21 @@ func TestDLsiteFetcher_Search_NotFound(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	results, err := f.Search(context.Background(), "RJ999999")
+	results, err := f.Search(context.Background(), service.Query{Text: "RJ999999"})
 	if err == nil {
 		t.Error("Expected error for 404, got nil")
 	}
 	if results != nil {
 		t.Error("Expected nil results for 404")
 	}
+
+	var nf *NotFoundError
+	if !errors.As(err, &nf) {
+		t.Errorf("Expected *NotFoundError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("Expected error to match service.ErrNotFound, got %v", err)
+	}
 }
 
 func TestDLsiteFetcher_ID(t *testing.T) {
@@ -168,7 +270,7 @@ func TestDLsiteFetcher_CacheTTL(t *testing.T) {
 func TestDLsiteFetcher_Search_NetworkError(t *testing.T) {
 	f := newTestFetcher("http://127.0.0.1:1") // unreachable port
 
-	_, err := f.Search(context.Background(), "RJ123456")
+	_, err := f.Search(context.Background(), service.Query{Text: "RJ123456"})
 	if err == nil {
 		t.Error("expected network error, got nil")
 	}
@@ -190,7 +292,7 @@ func TestDLsiteFetcher_ExtractCoverURL_SrcFallback(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	results, err := f.Search(context.Background(), "RJ010101")
+	results, err := f.Search(context.Background(), service.Query{Text: "RJ010101"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -218,7 +320,7 @@ func TestDLsiteFetcher_ExtractCoverURL_AbsoluteURL(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	results, err := f.Search(context.Background(), "RJ010101")
+	results, err := f.Search(context.Background(), service.Query{Text: "RJ010101"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -241,7 +343,7 @@ func TestDLsiteFetcher_ExtractCoverURL_NoCover(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	results, err := f.Search(context.Background(), "RJ010101")
+	results, err := f.Search(context.Background(), service.Query{Text: "RJ010101"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -274,8 +376,8 @@ func TestDLsiteFetcher_Search_Keyword(t *testing.T) {
 			_, _ = w.Write([]byte(mockHTML))
 			return
 		}
-		// Allow product page requests (return 404 to fallback to partial metadata)
-		if strings.Contains(r.URL.Path, "/product_id/") {
+		// Allow product page and info requests (return 404 to fallback to partial metadata)
+		if strings.Contains(r.URL.Path, "/product_id/") || r.URL.Path == productInfoPath {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
@@ -288,7 +390,7 @@ func TestDLsiteFetcher_Search_Keyword(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	results, err := f.Search(context.Background(), "some keyword")
+	results, err := f.Search(context.Background(), service.Query{Text: "some keyword"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -302,6 +404,48 @@ func TestDLsiteFetcher_Search_Keyword(t *testing.T) {
 	if results[0].ISBN != "RJ999999" {
 		t.Errorf("Expected ISBN 'RJ999999', got '%s'", results[0].ISBN)
 	}
+	// The search page does not tell the work's language.
+	if results[0].Language != "" {
+		t.Errorf("Expected no language for partial metadata, got '%s'", results[0].Language)
+	}
+}
+
+func TestDLsiteFetcher_Search_KeywordPrefersAuthor(t *testing.T) {
+	mockHTML := `
+	<html><body>
+		<table id="search_result_list">
+			<tr>
+				<td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ999999.html">Other Circle Work</a></td>
+				<td class="maker_name"><a href="#">Other Circle</a></td>
+			</tr>
+			<tr>
+				<td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ888888.html">Wanted Work</a></td>
+				<td class="maker_name"><a href="#">Wanted Circle</a></td>
+			</tr>
+		</table>
+	</body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/keyword/") {
+			_, _ = w.Write([]byte(mockHTML))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+
+	results, err := f.Search(context.Background(), service.Query{Title: "work", Author: "wanted circle"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].ISBN != "RJ888888" || results[1].ISBN != "RJ999999" {
+		t.Errorf("Expected author match first, got %s, %s", results[0].ISBN, results[1].ISBN)
+	}
 }
 
 func TestDLsiteFetcher_Search_KeywordWithSpaces(t *testing.T) {
@@ -331,7 +475,7 @@ func TestDLsiteFetcher_Search_KeywordWithSpaces(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	_, err := f.Search(context.Background(), "foo bar")
+	_, err := f.Search(context.Background(), service.Query{Text: "foo bar"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -367,7 +511,7 @@ func TestDLsiteFetcher_Search_AuthorNarratorSplit(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	results, err := f.Search(context.Background(), "split test")
+	results, err := f.Search(context.Background(), service.Query{Text: "split test"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -417,7 +561,7 @@ func TestDLsiteFetcher_Search_AuthorNarratorSplit_Grid(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	results, err := f.Search(context.Background(), "split test grid")
+	results, err := f.Search(context.Background(), service.Query{Text: "split test grid"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -481,7 +625,7 @@ func TestDLsiteFetcher_Search_Keyword_FullMetadata(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	results, err := f.Search(context.Background(), "keyword")
+	results, err := f.Search(context.Background(), service.Query{Text: "keyword"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -505,6 +649,299 @@ func TestDLsiteFetcher_Search_Keyword_FullMetadata(t *testing.T) {
 	}
 }
 
+func TestDLsiteFetcher_Search_KeywordEnrichmentBounded(t *testing.T) {
+	var rows strings.Builder
+	for _, code := range []string{"RJ100001", "RJ100002", "RJ100003", "RJ100004", "RJ100005"} {
+		rows.WriteString(`<tr><td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/` + code + `.html">Partial ` + code + `</a></td></tr>`)
+	}
+	searchHTML := `<html><body><table id="search_result_list">` + rows.String() + `</table></body></html>`
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/keyword/"):
+			_, _ = w.Write([]byte(searchHTML))
+		case r.URL.Path == productInfoPath:
+			w.WriteHeader(http.StatusNotFound)
+		case strings.Contains(r.URL.Path, "RJ100003"):
+			// Never answers before the per-item deadline.
+			<-r.Context().Done()
+		default:
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			_, _ = w.Write([]byte(`<html><body><h1 id="work_name">Full</h1></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+	f.enrichWorkers = 2
+	f.enrichTimeout = 200 * time.Millisecond
+
+	results, err := f.Search(context.Background(), service.Query{Text: "keyword"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	for i, r := range results {
+		want, wantLanguage := "Full", "Japanese"
+		if r.ISBN == "RJ100003" {
+			want, wantLanguage = "Partial RJ100003", ""
+		}
+		if r.Title != want || r.Language != wantLanguage {
+			t.Errorf("result %d (%s): expected title %q in %q, got %q in %q", i, r.ISBN, want, wantLanguage, r.Title, r.Language)
+		}
+	}
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent page fetches, got %d", got)
+	}
+}
+
+func TestDLsiteFetcher_Search_KeywordBudget(t *testing.T) {
+	searchHTML := `<html><body><table id="search_result_list">
+		<tr><td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ100001.html">Partial RJ100001</a></td></tr>
+		<tr><td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ100002.html">Partial RJ100002</a></td></tr>
+	</table></body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/keyword/") {
+			_, _ = w.Write([]byte(searchHTML))
+			return
+		}
+		// Work pages and product info never answer within the budget.
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+	f.enrichTimeout = time.Minute
+	f.searchBudget = 200 * time.Millisecond
+
+	start := time.Now()
+	results, err := f.Search(context.Background(), service.Query{Text: "keyword"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the search to stop at its budget, took %v", elapsed)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if want := "Partial " + r.ISBN; r.Title != want {
+			t.Errorf("expected partial title %q, got %q", want, r.Title)
+		}
+	}
+}
+
+func TestDLsiteFetcher_Search_KeywordEnrichesLeadingResults(t *testing.T) {
+	searchHTML := `<html><body><table id="search_result_list">
+		<tr><td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ100001.html">Partial RJ100001</a></td></tr>
+		<tr><td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ100002.html">Partial RJ100002</a></td></tr>
+		<tr><td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/RJ100003.html">Partial RJ100003</a></td></tr>
+	</table></body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/keyword/"):
+			_, _ = w.Write([]byte(searchHTML))
+		case r.URL.Path == productInfoPath:
+			w.WriteHeader(http.StatusNotFound)
+		case strings.Contains(r.URL.Path, "RJ100003"):
+			t.Errorf("unexpected fetch of a result past the enrichment limit: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = w.Write([]byte(`<html><body><h1 id="work_name">Full</h1></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+	f.enrichLimit = 2
+
+	results, err := f.Search(context.Background(), service.Query{Text: "keyword"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for i, want := range []string{"Full", "Full", "Partial RJ100003"} {
+		if results[i].Title != want {
+			t.Errorf("result %d: expected title %q, got %q", i, want, results[i].Title)
+		}
+	}
+}
+
+func TestEnrichLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		rt   http.RoundTripper
+		want int
+	}{
+		{"unlimited transport", nil, service.MaxSearchLimit},
+		{"default rate", transport.NewLimiter(nil), 15},
+		{"slow rate", transport.NewLimiter(nil, transport.WithRate(0.1, 1)), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := enrichLimit(tt.rt, 10*time.Second); got != tt.want {
+				t.Errorf("enrichLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDLsiteFetcher_GetWork_FetchesProductInfoAlongsidePage(t *testing.T) {
+	infoRequested := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == productInfoPath {
+			close(infoRequested)
+			_, _ = w.Write([]byte(`{"RJ123456":{"work_name":"From JSON"}}`))
+			return
+		}
+		// The page only answers once the product info has been asked for.
+		select {
+		case <-infoRequested:
+		case <-time.After(2 * time.Second):
+			t.Error("product info was not requested while the work page was loading")
+		}
+		_, _ = w.Write([]byte(`<html><body><h1 id="work_name">From page</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+	code, _ := NewProductCode("RJ123456")
+	work, err := f.getWorkByID(context.Background(), code)
+	if err != nil {
+		t.Fatalf("getWorkByID failed: %v", err)
+	}
+	if work.Title != "From JSON" {
+		t.Errorf("expected the product info title, got %q", work.Title)
+	}
+}
+
+func TestDLsiteFetcher_Search_KeywordPagination(t *testing.T) {
+	// Two full DLsite pages of 30 works and a short third one of 5.
+	sitePage := func(page, n int) string {
+		var rows strings.Builder
+		for i := range n {
+			code := fmt.Sprintf("RJ%06d", page*100+i)
+			rows.WriteString(`<tr><td class="work_name"><a href="https://www.dlsite.com/maniax/work/=/product_id/` + code + `.html">` + code + `</a></td></tr>`)
+		}
+		return `<html><body><table id="search_result_list">` + rows.String() + `</table></body></html>`
+	}
+	const search = "/maniax/fsr/=/keyword/foo/work_category[0]/doujin/work_category[1]/pc/work_category[2]/books"
+	pages := map[string]string{
+		search + "/per_page/30":        sitePage(1, 30),
+		search + "/per_page/30/page/2": sitePage(2, 30),
+		search + "/per_page/30/page/3": sitePage(3, 5),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if page, ok := pages[r.URL.Path]; ok {
+			_, _ = w.Write([]byte(page))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+
+	tests := []struct {
+		name        string
+		limit, page int
+		first, last string
+		count       int
+	}{
+		{"default", 0, 0, "RJ000100", "RJ000104", 5},
+		{"spanning site pages", 20, 2, "RJ000120", "RJ000209", 20},
+		{"last page is short", 25, 3, "RJ000220", "RJ000304", 15},
+		{"beyond the end", 50, 3, "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := f.Search(context.Background(), service.Query{Text: "foo", Limit: tt.limit, Page: tt.page})
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(results) != tt.count {
+				t.Fatalf("expected %d results, got %d", tt.count, len(results))
+			}
+			if tt.count > 0 && (results[0].ISBN != tt.first || results[len(results)-1].ISBN != tt.last) {
+				t.Errorf("expected %s..%s, got %s..%s", tt.first, tt.last, results[0].ISBN, results[len(results)-1].ISBN)
+			}
+		})
+	}
+}
+
+func TestDLsiteFetcher_SearchURL(t *testing.T) {
+	f := newTestFetcher("https://dlsite.test")
+
+	tests := []struct {
+		name    string
+		query   string
+		filters service.Filters
+		page    int
+		want    string
+	}{
+		{"keyword only", "foo bar", service.Filters{}, 1, "https://dlsite.test/maniax/fsr/=/keyword/foo+bar/work_category%5B0%5D/doujin/work_category%5B1%5D/pc/work_category%5B2%5D/books/per_page/30"},
+		{
+			"all filters",
+			"foo",
+			service.Filters{Age: service.AgeR18, VoiceActor: "CV Name", Maker: "Circle", Genre: "497", ReleasedAfter: "2024-01-01", ReleasedBefore: "2024-12-31"},
+			2,
+			"https://dlsite.test/maniax/fsr/=/keyword/foo/work_category%5B0%5D/doujin/work_category%5B1%5D/pc/work_category%5B2%5D/books/age_category%5B0%5D/adult/keyword_maker_name/Circle/keyword_creater/CV+Name/genre%5B0%5D/497/regist_date_start/2024-01-01/regist_date_end/2024-12-31/per_page/30/page/2",
+		},
+		{"filters without keyword", "", service.Filters{Age: service.AgeAll}, 1, "https://dlsite.test/maniax/fsr/=/work_category%5B0%5D/doujin/work_category%5B1%5D/pc/work_category%5B2%5D/books/age_category%5B0%5D/general/per_page/30"},
+		{"genre name", "foo", service.Filters{Genre: "ASMR"}, 1, "https://dlsite.test/maniax/fsr/=/keyword/foo+ASMR/work_category%5B0%5D/doujin/work_category%5B1%5D/pc/work_category%5B2%5D/books/per_page/30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.searchURL(tt.query, tt.filters, tt.page); got != tt.want {
+				t.Errorf("searchURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDLsiteFetcher_Search_KeywordFindsEveryProductFamily(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/maniax/fsr/=/keyword/voice/work_category[0]/doujin/work_category[1]/pc/work_category[2]/books/per_page/30":
+			_, _ = w.Write([]byte(`<html><body><table id="search_result_list"><tr>
+				<td class="work_name"><a href="https://www.dlsite.com/pro/work/=/product_id/VJ012345.html">Commercial Voice</a></td>
+			</tr></table></body></html>`))
+		case "/pro/work/=/product_id/VJ012345.html":
+			_, _ = w.Write([]byte(`<html><body><h1 id="work_name">Commercial Voice</h1><span class="maker_name"><a href="#">Brand</a></span></body></html>`))
+		case productInfoPath:
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	results, err := newTestFetcher(server.URL).Search(context.Background(), service.Query{Text: "voice"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ISBN != "VJ012345" || results[0].Author != "Brand" {
+		t.Errorf("expected the commercial work found by keyword, got %+v", results)
+	}
+}
+
 func TestDLsiteFetcher_ExtractDescription_Fallback(t *testing.T) {
 	mockHTML := `<html><head><meta property="og:description" content="Meta Description"></head><body></body></html>`
 
@@ -516,7 +953,7 @@ func TestDLsiteFetcher_ExtractDescription_Fallback(t *testing.T) {
 
 	f := newTestFetcher(server.URL)
 
-	results, err := f.Search(context.Background(), "RJ010101")
+	results, err := f.Search(context.Background(), service.Query{Text: "RJ010101"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -570,7 +1007,7 @@ func TestDLsiteFetcher_SeriesExtraction(t *testing.T) {
 		baseURL: server.URL,
 	}
 
-	rj, _ := NewRJCode("RJ999999")
+	rj, _ := NewProductCode("RJ999999")
 	work, err := f.getWorkByID(context.Background(), rj)
 	if err != nil {
 		t.Fatalf("Failed to get work: %v", err)
@@ -604,7 +1041,7 @@ func TestDLsiteFetcher_SeriesExtraction_NoLink(t *testing.T) {
 		baseURL: server.URL,
 	}
 
-	rj, _ := NewRJCode("RJ999998")
+	rj, _ := NewProductCode("RJ999998")
 	work, err := f.getWorkByID(context.Background(), rj)
 	if err != nil {
 		t.Fatalf("Failed to get work: %v", err)
@@ -614,3 +1051,85 @@ func TestDLsiteFetcher_SeriesExtraction_NoLink(t *testing.T) {
 		t.Errorf("Expected Series 'Standalone Series', got '%s'", work.Series)
 	}
 }
+
+func TestDLsiteFetcher_GetWork(t *testing.T) {
+	mockHTML := `
+	<html><body>
+		<h1 id="work_name">Detail Title</h1>
+		<span class="maker_name"><a href="#">Detail Circle</a></span>
+		<div class="work_buy_content"><span class="price">1,320<i>円</i></span></div>
+		<table id="work_outline">
+			<tr><th>販売日</th><td><a href="#">2024年02月03日</a></td></tr>
+			<tr><th>シナリオ</th><td><a href="#">Writer</a></td></tr>
+			<tr><th>声優</th><td><a href="#">CV1</a><a href="#">CV2</a></td></tr>
+			<tr><th>年齢指定</th><td><a href="#">全年齢</a></td></tr>
+			<tr><th>作品形式</th><td><a href="#">ボイス・ASMR</a></td></tr>
+		</table>
+	</body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/maniax/work/=/product_id/RJ010101.html" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(mockHTML))
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+
+	work, err := f.GetWork(context.Background(), "rj010101")
+	if err != nil {
+		t.Fatalf("GetWork failed: %v", err)
+	}
+
+	if work.ID != "RJ010101" || work.Provider != "dlsite" || work.Title != "Detail Title" {
+		t.Errorf("unexpected identity fields: %+v", work)
+	}
+	if work.URL != server.URL+"/maniax/work/=/product_id/RJ010101.html" {
+		t.Errorf("unexpected URL %q", work.URL)
+	}
+	if work.Price != 1320 {
+		t.Errorf("expected price 1320, got %d", work.Price)
+	}
+	if work.ReleaseDate != "2024-02-03" {
+		t.Errorf("expected release date 2024-02-03, got %q", work.ReleaseDate)
+	}
+	if work.Scenario != "Writer" || work.Circle != "Detail Circle" {
+		t.Errorf("unexpected scenario/circle: %q / %q", work.Scenario, work.Circle)
+	}
+	if work.AgeRating != "全年齢" || work.Explicit {
+		t.Errorf("unexpected age rating %q (explicit=%v)", work.AgeRating, work.Explicit)
+	}
+	if len(work.VoiceActors) != 2 {
+		t.Errorf("expected 2 voice actors, got %v", work.VoiceActors)
+	}
+}
+
+func TestDLsiteFetcher_GetWork_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(server.URL)
+
+	for _, id := range []string{"RJ999999", "not-a-code"} {
+		if _, err := f.GetWork(context.Background(), id); !errors.Is(err, service.ErrNotFound) {
+			t.Errorf("GetWork(%q): expected ErrNotFound, got %v", id, err)
+		}
+	}
+}
+
+func TestDLsiteFetcher_Describe(t *testing.T) {
+	f := newTestFetcher("http://example.invalid")
+	info := f.Describe()
+	if info.Name != "DLsite" || len(info.QueryKinds) != 2 || !info.AdultContent {
+		t.Errorf("unexpected info with age check disabled: %+v", info)
+	}
+
+	f.ageCheckDisabled = false
+	if f.Describe().AdultContent {
+		t.Error("expected no adult content support while the age check is active")
+	}
+}
@@ -0,0 +1,144 @@
+package dlsite
+
+import (
+	"log/slog"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+const (
+	// degradedAfterInvalid is the number of consecutive work pages failing validation
+	// after which the parser is reported degraded.
+	degradedAfterInvalid = 3
+	// maxTrackedHeaders bounds the number of distinct unknown headers counted.
+	maxTrackedHeaders = 32
+)
+
+// Mandatory parts of a work page. Every DLsite work page has them, so a page
+// without one means that the selectors no longer match the markup.
+const (
+	fieldTitle   = "title"
+	fieldCircle  = "circle"
+	fieldCover   = "cover"
+	fieldOutline = "outline"
+)
+
+// pageReport is the result of validating a parsed work page.
+type pageReport struct {
+	missing        []string
+	unknownHeaders []string
+}
+
+func (r pageReport) valid() bool {
+	return len(r.missing) == 0
+}
+
+// validateWorkPage checks the fields extracted from a work page, before the
+// product info overlays them: the product info would hide selectors that broke.
+func validateWorkPage(doc *goquery.Document, work AsmrWork) pageReport {
+	var r pageReport
+	if work.Title == "" {
+		r.missing = append(r.missing, fieldTitle)
+	}
+	if work.Circle == "" {
+		r.missing = append(r.missing, fieldCircle)
+	}
+	if work.CoverURL == "" {
+		r.missing = append(r.missing, fieldCover)
+	}
+
+	rows := doc.Find("#work_outline tr")
+	if rows.Length() == 0 {
+		r.missing = append(r.missing, fieldOutline)
+	}
+	rows.Each(func(_ int, s *goquery.Selection) {
+		header := strings.TrimSpace(s.Find("th").Text())
+		if header != "" && !knownHeader(header) {
+			r.unknownHeaders = append(r.unknownHeaders, header)
+		}
+	})
+	return r
+}
+
+// knownHeader reports whether header is the label of a known "#work_outline" row.
+func knownHeader(header string) bool {
+	for _, labels := range outlineHeaders {
+		if containsAny(header, labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// parserMonitor keeps the validation counters of the parsed work pages and
+// detects when DLsite's markup drifted away from the selectors.
+// The zero value is ready to use.
+type parserMonitor struct {
+	mu            sync.Mutex
+	stats         service.ParserStats
+	invalidStreak int
+}
+
+// record counts the validation result of the work page of code.
+func (m *parserMonitor) record(code ProductCode, r pageReport) {
+	if len(r.unknownHeaders) > 0 {
+		slog.Warn("Unknown DLsite work outline headers", "code", code.String(), "headers", r.unknownHeaders)
+	}
+	if !r.valid() {
+		slog.Warn("DLsite work page is missing mandatory fields, the markup may have changed", "code", code.String(), "missing", r.missing)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats.Validated++
+	for _, header := range r.unknownHeaders {
+		if m.stats.UnknownHeaders == nil {
+			m.stats.UnknownHeaders = make(map[string]int64)
+		}
+		if _, ok := m.stats.UnknownHeaders[header]; ok || len(m.stats.UnknownHeaders) < maxTrackedHeaders {
+			m.stats.UnknownHeaders[header]++
+		}
+	}
+
+	if r.valid() {
+		if m.invalidStreak >= degradedAfterInvalid {
+			slog.Info("DLsite parser recovered", "code", code.String())
+		}
+		m.invalidStreak = 0
+		return
+	}
+
+	m.stats.Invalid++
+	m.stats.LastInvalid = code.String()
+	if m.stats.MissingFields == nil {
+		m.stats.MissingFields = make(map[string]int64)
+	}
+	for _, field := range r.missing {
+		m.stats.MissingFields[field]++
+	}
+	m.invalidStreak++
+	if m.invalidStreak == degradedAfterInvalid {
+		slog.Error("DLsite parser degraded", "invalidPages", m.invalidStreak, "code", code.String(), "missing", r.missing)
+	}
+}
+
+// snapshot returns a copy of the counters.
+func (m *parserMonitor) snapshot() service.ParserStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.stats
+	s.State = service.ParserOK
+	if m.invalidStreak >= degradedAfterInvalid {
+		s.State = service.ParserDegraded
+	}
+	s.MissingFields = maps.Clone(m.stats.MissingFields)
+	s.UnknownHeaders = maps.Clone(m.stats.UnknownHeaders)
+	return s
+}
@@ -0,0 +1,124 @@
+package dlsite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+func TestValidateWorkPage(t *testing.T) {
+	tests := []struct {
+		name        string
+		html        string
+		wantMissing []string
+		wantUnknown []string
+	}{
+		{
+			name: "complete page",
+			html: `<h1 id="work_name">Title</h1>
+				<span class="maker_name"><a href="#">Circle</a></span>
+				<div class="product-slider-data"><div data-src="//img.dlsite.jp/cover.jpg"></div></div>
+				<table id="work_outline">
+					<tr><th>販売日</th><td>2024年01月02日</td></tr>
+					<tr><th>シリーズ名</th><td>Series</td></tr>
+					<tr><th>ファイル容量</th><td>1GB</td></tr>
+				</table>`,
+		},
+		{
+			name: "renamed selectors",
+			html: `<h1 class="work-title">Title</h1>
+				<div class="work_outline_v2"><dl><dt>販売日</dt><dd>2024年01月02日</dd></dl></div>`,
+			wantMissing: []string{fieldTitle, fieldCircle, fieldCover, fieldOutline},
+		},
+		{
+			name: "unknown headers",
+			html: `<h1 id="work_name">Title</h1>
+				<span class="maker_name"><a href="#">Circle</a></span>
+				<div class="product-slider-data"><div src="https://img.dlsite.jp/cover.jpg"></div></div>
+				<table id="work_outline">
+					<tr><th>販売日</th><td>2024年01月02日</td></tr>
+					<tr><th>出演者</th><td>CV</td></tr>
+				</table>`,
+			wantUnknown: []string{"出演者"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := &dlsiteFetcher{}
+			work := AsmrWork{Title: f.extractTitle(doc), Circle: f.extractCircle(doc), CoverURL: f.extractCoverURL(doc)}
+
+			r := validateWorkPage(doc, work)
+			if !slices.Equal(r.missing, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", r.missing, tt.wantMissing)
+			}
+			if !slices.Equal(r.unknownHeaders, tt.wantUnknown) {
+				t.Errorf("unknown headers = %v, want %v", r.unknownHeaders, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestParserMonitor_DegradesAndRecovers(t *testing.T) {
+	var m parserMonitor
+	code, _ := NewProductCode("RJ01234567")
+	invalid := pageReport{missing: []string{fieldTitle}, unknownHeaders: []string{"出演者"}}
+
+	for i := 0; i < degradedAfterInvalid-1; i++ {
+		m.record(code, invalid)
+	}
+	if got := m.snapshot().State; got != service.ParserOK {
+		t.Fatalf("expected the parser to tolerate %d invalid pages, got %s", degradedAfterInvalid-1, got)
+	}
+
+	m.record(code, invalid)
+	stats := m.snapshot()
+	if stats.State != service.ParserDegraded {
+		t.Fatalf("expected the parser to be degraded, got %s", stats.State)
+	}
+	if stats.Invalid != degradedAfterInvalid || stats.MissingFields[fieldTitle] != degradedAfterInvalid ||
+		stats.UnknownHeaders["出演者"] != degradedAfterInvalid || stats.LastInvalid != "RJ01234567" {
+		t.Errorf("unexpected counters: %+v", stats)
+	}
+
+	m.record(code, pageReport{})
+	if stats := m.snapshot(); stats.State != service.ParserOK || stats.Validated != degradedAfterInvalid+1 {
+		t.Errorf("expected a valid page to recover the parser, got %+v", stats)
+	}
+}
+
+func TestDLsiteFetcher_ParserStats(t *testing.T) {
+	// The markup changed: none of the selectors match any more.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/work/") {
+			_, _ = w.Write([]byte(`<html><body><h1 class="work-title">Title</h1></body></html>`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	f := newTestFetcher(ts.URL)
+	for _, id := range []string{"RJ01000001", "RJ01000002", "RJ01000003"} {
+		code, _ := NewProductCode(id)
+		if _, err := f.getWorkByID(context.Background(), code); err != nil {
+			t.Fatalf("getWorkByID(%s) failed: %v", id, err)
+		}
+	}
+
+	stats := f.ParserStats()
+	if stats.State != service.ParserDegraded || stats.Invalid != 3 || stats.LastInvalid != "RJ01000003" {
+		t.Errorf("expected a degraded parser after 3 unparsable pages, got %+v", stats)
+	}
+}
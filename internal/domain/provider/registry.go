@@ -1,16 +1,36 @@
 package provider
 
 import (
+	"net/http"
+
 	"audiobookshelf-asmr-provider/internal/domain/provider/all"
+	"audiobookshelf-asmr-provider/internal/domain/provider/breaker"
 	"audiobookshelf-asmr-provider/internal/domain/provider/dlsite"
 	"audiobookshelf-asmr-provider/internal/domain/provider/void"
 	"audiobookshelf-asmr-provider/internal/service"
 )
 
+// Options configures the providers created by NewAllWithOptions.
+type Options struct {
+	// Aggregation configures the aggregation provider.
+	Aggregation all.Options
+	// Transport is shared by the HTTP clients of the scraping providers, e.g. to
+	// apply rate limits across all of them. Nil selects http.DefaultTransport.
+	Transport http.RoundTripper
+	// Breaker configures the circuit breakers guarding the upstream providers.
+	Breaker breaker.Options
+}
+
 // NewAll instantiates and returns all available providers.
 func NewAll() []service.Provider {
-	dlsiteProvider := dlsite.NewDLsiteFetcher()
-	allProvider := all.NewProvider(dlsiteProvider)
+	return NewAllWithOptions(Options{})
+}
+
+// NewAllWithOptions instantiates all available providers with custom options.
+func NewAllWithOptions(opts Options) []service.Provider {
+	// The aggregation provider shares the breakers, so that it fails fast as well.
+	dlsiteProvider := breaker.Wrap(dlsite.NewDLsiteFetcherWithTransport(opts.Transport), opts.Breaker)
+	allProvider := all.NewProviderWithOptions(opts.Aggregation, dlsiteProvider)
 	voidProvider := void.NewProvider()
 
 	return []service.Provider{
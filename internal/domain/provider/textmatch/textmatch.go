@@ -0,0 +1,73 @@
+// Package textmatch normalises titles and names for comparison across providers.
+package textmatch
+
+import (
+	"strings"
+	"unicode"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+// nameSeparators split author strings into individual names, e.g. "Circle / CV1, CV2".
+const nameSeparators = ",、，/／&＆"
+
+// ToHalfWidth folds full-width ASCII variants (e.g. "ＲＪ０１２３") and the
+// ideographic space to their ASCII equivalents.
+func ToHalfWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '！' && r <= '～':
+			return r - '！' + '!'
+		case r == '　':
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// Normalize folds text for comparison: full-width ASCII becomes half-width,
+// katakana becomes hiragana, letters are lowercased, and everything but letters
+// and digits (spaces, punctuation, brackets such as 【】) is dropped.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range ToHalfWidth(s) {
+		if r >= 'ァ' && r <= 'ヶ' {
+			r -= 'ァ' - 'ぁ'
+		}
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// SplitNames splits an author string into normalised names.
+func SplitNames(s string) []string {
+	var names []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(nameSeparators, r)
+	}) {
+		if name := Normalize(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// MatchesAnyName reports whether any of the names, as returned by SplitNames,
+// appears in the author, narrator or publisher of r.
+func MatchesAnyName(names []string, r service.AbsBookMetadata) bool {
+	if len(names) == 0 {
+		return false
+	}
+	candidates := append(SplitNames(r.Author), SplitNames(r.Narrator)...)
+	candidates = append(candidates, SplitNames(r.Publisher)...)
+	for _, name := range names {
+		for _, c := range candidates {
+			if strings.Contains(c, name) || strings.Contains(name, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package textmatch
+
+import (
+	"slices"
+	"testing"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"【耳かき】癒やしのオトメ": "耳かき癒やしのおとめ",
+		"ＡＳＭＲ　Ｔｉｔｌｅ":   "asmrtitle",
+		"Circle Name!": "circlename",
+		"":             "",
+	}
+	for input, want := range tests {
+		if got := Normalize(input); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSplitNames(t *testing.T) {
+	got := SplitNames("Circle A / CV１、ＣＶ2 & ")
+	want := []string{"circlea", "cv1", "cv2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("SplitNames = %v, want %v", got, want)
+	}
+}
+
+func TestMatchesAnyName(t *testing.T) {
+	r := service.AbsBookMetadata{Author: "Writer", Narrator: "CV A, CV B", Publisher: "Circle A"}
+	tests := []struct {
+		author string
+		want   bool
+	}{
+		{"circle a", true},
+		{"ＣＶ Ｂ", true},
+		{"Circle", true}, // partial names match
+		{"Other", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		if got := MatchesAnyName(SplitNames(tc.author), r); got != tc.want {
+			t.Errorf("MatchesAnyName(%q) = %v, want %v", tc.author, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRate     = 3.0
+	defaultBurst    = 6
+	defaultMaxConns = 4
+
+	// slowWait is the queue wait above which a throttled request is logged at info level.
+	slowWait = time.Second
+)
+
+// LimitOption configures a Limiter.
+type LimitOption func(*Limiter)
+
+// WithRate sets the sustained number of requests per second allowed to each host
+// and how many requests may be made at once after a quiet period.
+// Values <= 0 keep the defaults.
+func WithRate(perSecond float64, burst int) LimitOption {
+	return func(l *Limiter) {
+		if perSecond > 0 {
+			l.rate = perSecond
+		}
+		if burst > 0 {
+			l.burst = burst
+		}
+	}
+}
+
+// WithMaxConns sets the maximum number of requests in flight to each host.
+// Values <= 0 keep the default.
+func WithMaxConns(n int) LimitOption {
+	return func(l *Limiter) {
+		if n > 0 {
+			l.maxConns = n
+		}
+	}
+}
+
+// Limiter is an http.RoundTripper that keeps the load on each upstream host polite:
+// requests are spaced out by a per-host token bucket, and only a bounded number of
+// them may be in flight per host at once. A request counts as in flight until its
+// response body is closed. One Limiter is meant to be shared by every provider, so
+// that the limits hold for the process as a whole.
+type Limiter struct {
+	base     http.RoundTripper
+	rate     float64
+	burst    int
+	maxConns int
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+// hostLimiter is the token bucket, connection slots and counters of one host.
+type hostLimiter struct {
+	conns chan struct{}
+
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	stats   HostStats
+	maxWait time.Duration
+	waited  time.Duration
+}
+
+// HostStats is a snapshot of the outbound request counters of one host.
+type HostStats struct {
+	Host string `json:"host"`
+	// Requests is the number of requests sent to the host.
+	Requests int64 `json:"requests"`
+	// Throttled is the number of requests that had to wait for their turn.
+	Throttled int64 `json:"throttled"`
+	// WaitSeconds is the total time requests spent waiting for their turn.
+	WaitSeconds float64 `json:"waitSeconds"`
+	// MaxWaitSeconds is the longest time a single request waited.
+	MaxWaitSeconds float64 `json:"maxWaitSeconds"`
+	// InFlight is the number of requests currently in flight.
+	InFlight int `json:"inFlight"`
+}
+
+// NewLimiter wraps base, or http.DefaultTransport if base is nil, in a Limiter.
+func NewLimiter(base http.RoundTripper, opts ...LimitOption) *Limiter {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	l := &Limiter{
+		base:     base,
+		rate:     defaultRate,
+		burst:    defaultBurst,
+		maxConns: defaultMaxConns,
+		hosts:    make(map[string]*hostLimiter),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// RoundTrip implements http.RoundTripper. It waits for the request's turn, giving
+// up when the request's context is done.
+func (l *Limiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := l.host(req.URL.Host)
+	ctx := req.Context()
+
+	wait, err := host.acquire(ctx, l.rate, l.burst)
+	if err != nil {
+		return nil, err
+	}
+	host.record(wait)
+	if wait > 0 {
+		level := slog.LevelDebug
+		if wait >= slowWait {
+			level = slog.LevelInfo
+		}
+		slog.Log(ctx, level, "Throttled upstream request", "host", req.URL.Host, "url", req.URL.Redacted(), "wait", wait)
+	}
+
+	resp, err := l.base.RoundTrip(req)
+	if err != nil {
+		host.release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: host.release}
+	return resp, nil
+}
+
+// Rate returns the sustained number of requests per second allowed to each host.
+func (l *Limiter) Rate() float64 {
+	return l.rate
+}
+
+// Stats returns a snapshot of the counters of every host contacted so far, sorted by host.
+func (l *Limiter) Stats() []HostStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make([]HostStats, 0, len(l.hosts))
+	for name, h := range l.hosts {
+		h.mu.Lock()
+		s := h.stats
+		s.Host = name
+		s.WaitSeconds = h.waited.Seconds()
+		s.MaxWaitSeconds = h.maxWait.Seconds()
+		h.mu.Unlock()
+		s.InFlight = len(h.conns)
+		stats = append(stats, s)
+	}
+	slices.SortFunc(stats, func(a, b HostStats) int { return strings.Compare(a.Host, b.Host) })
+	return stats
+}
+
+func (l *Limiter) host(name string) *hostLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.hosts[name]
+	if !ok {
+		h = &hostLimiter{
+			conns:  make(chan struct{}, l.maxConns),
+			tokens: float64(l.burst),
+			last:   time.Now(),
+		}
+		l.hosts[name] = h
+	}
+	return h
+}
+
+// acquire waits for a token and then for a connection slot, and returns how long
+// it had to wait; zero means the request could go ahead immediately.
+func (h *hostLimiter) acquire(ctx context.Context, rate float64, burst int) (time.Duration, error) {
+	start := time.Now()
+	blocked := false
+
+	if delay := h.reserve(rate, burst); delay > 0 {
+		blocked = true
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			h.cancelReservation()
+			return 0, ctx.Err()
+		}
+	}
+
+	select {
+	case h.conns <- struct{}{}:
+	default:
+		blocked = true
+		select {
+		case h.conns <- struct{}{}:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	if !blocked {
+		return 0, nil
+	}
+	return time.Since(start), nil
+}
+
+// reserve takes a token from the bucket, possibly ahead of time, and returns how
+// long to wait until it is due.
+func (h *hostLimiter) reserve(rate float64, burst int) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.tokens = min(h.tokens+now.Sub(h.last).Seconds()*rate, float64(burst))
+	h.last = now
+	h.tokens--
+	if h.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-h.tokens / rate * float64(time.Second))
+}
+
+// cancelReservation returns the token of a request that gave up waiting.
+func (h *hostLimiter) cancelReservation() {
+	h.mu.Lock()
+	h.tokens++
+	h.mu.Unlock()
+}
+
+func (h *hostLimiter) release() {
+	<-h.conns
+}
+
+func (h *hostLimiter) record(wait time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stats.Requests++
+	if wait > 0 {
+		h.stats.Throttled++
+		h.waited += wait
+		h.maxWait = max(h.maxWait, wait)
+	}
+}
+
+// releasingBody frees the request's connection slot when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func okServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	resp.Body.Close()
+}
+
+func TestLimiter_Rate(t *testing.T) {
+	srv := okServer(t)
+	l := NewLimiter(nil, WithRate(20, 1))
+	client := &http.Client{Transport: l}
+
+	start := time.Now()
+	for range 3 {
+		get(t, client, srv.URL)
+	}
+	// The first request uses the burst; the other two wait 50ms each.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected requests to be spaced out, took %v", elapsed)
+	}
+
+	stats := l.Stats()
+	if len(stats) != 1 || stats[0].Requests != 3 || stats[0].Throttled != 2 || stats[0].WaitSeconds <= 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestLimiter_PerHost(t *testing.T) {
+	srv1, srv2 := okServer(t), okServer(t)
+	l := NewLimiter(nil, WithRate(1, 1))
+	client := &http.Client{Transport: l}
+
+	start := time.Now()
+	get(t, client, srv1.URL)
+	get(t, client, srv2.URL)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected each host to have its own bucket, took %v", elapsed)
+	}
+	if stats := l.Stats(); len(stats) != 2 || stats[0].Throttled+stats[1].Throttled != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestLimiter_MaxConns(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	l := NewLimiter(nil, WithRate(1000, 100), WithMaxConns(2))
+	client := &http.Client{Transport: l}
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Go(func() { get(t, client, srv.URL) })
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("expected at most 2 concurrent requests, saw %d", p)
+	}
+	if stats := l.Stats(); stats[0].InFlight != 0 || stats[0].Throttled == 0 {
+		t.Errorf("expected queued requests and all slots released, got %+v", stats)
+	}
+}
+
+func TestLimiter_SlotHeldUntilBodyClosed(t *testing.T) {
+	srv := okServer(t)
+	client := &http.Client{Transport: NewLimiter(nil, WithRate(1000, 100), WithMaxConns(1))}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the second request to wait for the open body, got %v", err)
+	}
+
+	resp.Body.Close()
+	get(t, client, srv.URL)
+}
+
+func TestLimiter_ContextCancelledWhileWaiting(t *testing.T) {
+	srv := okServer(t)
+	l := NewLimiter(nil, WithRate(1, 1))
+	client := &http.Client{Transport: l}
+	get(t, client, srv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+
+	start := time.Now()
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context's error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected to stop waiting when the context ended, waited %v", elapsed)
+	}
+	if stats := l.Stats(); stats[0].Requests != 1 {
+		t.Errorf("expected the abandoned request not to be counted, got %+v", stats)
+	}
+}
@@ -0,0 +1,197 @@
+// Package transport provides http.RoundTripper middleware shared by the providers.
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 500 * time.Millisecond
+	defaultMaxDelay    = 10 * time.Second
+
+	// maxDrainBytes bounds how much of a discarded response is read so that its
+	// connection can be reused.
+	maxDrainBytes = 64 << 10
+)
+
+// retryableStatuses are the response statuses that indicate a transient upstream problem.
+var retryableStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// RetryOption configures a Retry transport.
+type RetryOption func(*Retry)
+
+// WithMaxAttempts sets the number of attempts made per request, including the first.
+// Values <= 0 keep the default.
+func WithMaxAttempts(n int) RetryOption {
+	return func(t *Retry) {
+		if n > 0 {
+			t.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the cap on any delay.
+// A response whose Retry-After asks for a longer delay is not retried.
+// Values <= 0 keep the defaults.
+func WithBackoff(base, max time.Duration) RetryOption {
+	return func(t *Retry) {
+		if base > 0 {
+			t.baseDelay = base
+		}
+		if max > 0 {
+			t.maxDelay = max
+		}
+	}
+}
+
+// Retry is an http.RoundTripper that retries idempotent requests on network errors
+// and on transient statuses (429, 502, 503, 504). Retries are delayed by an
+// exponential backoff with jitter, or by the server's Retry-After if it sends one,
+// and stop as soon as the request's context is done. A response is returned as is
+// when its Retry-After exceeds the maximum delay or the request's deadline, since
+// retrying earlier than the server asked would only add to its load.
+type Retry struct {
+	base        http.RoundTripper
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// NewRetry wraps base, or http.DefaultTransport if base is nil, in a Retry transport.
+func NewRetry(base http.RoundTripper, opts ...RetryOption) *Retry {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &Retry{
+		base:        base,
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		maxDelay:    defaultMaxDelay,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Retry) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !idempotent(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.maxAttempts || !retryable(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		reason := "network error"
+		if err == nil {
+			reason = resp.Status
+			if after, ok := retryAfter(resp, time.Now()); ok {
+				if !t.canWait(ctx, after) {
+					slog.Debug("Not retrying upstream request, Retry-After too long", "url", req.URL.Redacted(), "retryAfter", after)
+					return resp, nil
+				}
+				delay = after
+			}
+			discard(resp)
+		}
+		slog.Debug("Retrying upstream request", "url", req.URL.Redacted(), "attempt", attempt, "reason", reason, "error", err, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// backoff returns the delay before retry number attempt: an exponentially growing
+// delay, capped at the maximum, of which a random half is taken off so that
+// clients failing together do not retry together.
+func (t *Retry) backoff(attempt int) time.Duration {
+	d := t.baseDelay << (attempt - 1)
+	if d <= 0 || d > t.maxDelay {
+		d = t.maxDelay
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// canWait reports whether a retry may be delayed by d: within the maximum delay
+// and before the request's deadline.
+func (t *Retry) canWait(ctx context.Context, d time.Duration) bool {
+	if d > t.maxDelay {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
+
+// idempotent reports whether req may safely be sent more than once.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryable reports whether the outcome of a round trip is worth retrying.
+// Errors other than the end of the request's context are network errors such as
+// refused or reset connections.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return retryableStatuses[resp.StatusCode]
+}
+
+// retryAfter parses the Retry-After header, given either in seconds or as an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// discard drains and closes a response that is not returned to the caller.
+func discard(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+	resp.Body.Close()
+}
@@ -0,0 +1,223 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusServer responds with the given statuses in turn, repeating the last one.
+func statusServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := int(calls.Add(1))
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetry_RetriesTransientStatuses(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		srv, calls := statusServer(t, nil, status, http.StatusOK)
+		client := &http.Client{Transport: NewRetry(nil, WithBackoff(time.Millisecond, 10*time.Millisecond))}
+
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", status, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+			t.Errorf("%d: expected success on the second attempt, got %d after %d calls", status, resp.StatusCode, calls.Load())
+		}
+	}
+}
+
+func TestRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	srv, calls := statusServer(t, nil, http.StatusServiceUnavailable)
+	client := &http.Client{Transport: NewRetry(nil, WithMaxAttempts(3), WithBackoff(time.Millisecond, time.Millisecond))}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 3 {
+		t.Errorf("expected the last response after 3 attempts, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetry_DoesNotRetryOtherResponses(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusInternalServerError} {
+		srv, calls := statusServer(t, nil, status, http.StatusOK)
+		client := &http.Client{Transport: NewRetry(nil, WithBackoff(time.Millisecond, time.Millisecond))}
+
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", status, err)
+		}
+		resp.Body.Close()
+		if calls.Load() != 1 {
+			t.Errorf("%d: expected a single attempt, got %d", status, calls.Load())
+		}
+	}
+
+	srv, calls := statusServer(t, nil, http.StatusServiceUnavailable, http.StatusOK)
+	client := &http.Client{Transport: NewRetry(nil, WithBackoff(time.Millisecond, time.Millisecond))}
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("POST: unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("expected POST requests not to be retried, got %d attempts", calls.Load())
+	}
+}
+
+func TestRetry_RetriesNetworkErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	url := srv.URL
+	srv.Close() // Connections are refused from now on.
+
+	var attempts atomic.Int32
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	client := &http.Client{Transport: NewRetry(base, WithBackoff(time.Millisecond, time.Millisecond))}
+
+	if _, err := client.Get(url); err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if attempts.Load() != defaultMaxAttempts {
+		t.Errorf("expected %d attempts, got %d", defaultMaxAttempts, attempts.Load())
+	}
+}
+
+func TestRetry_HonoursRetryAfter(t *testing.T) {
+	srv, calls := statusServer(t, http.Header{"Retry-After": {"1"}}, http.StatusTooManyRequests, http.StatusOK)
+	// The backoff alone would retry immediately; Retry-After asks for a second.
+	client := &http.Client{Transport: NewRetry(nil, WithBackoff(time.Nanosecond, 5*time.Second))}
+
+	start := time.Now()
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 3*time.Second {
+		t.Errorf("expected the retry to wait for Retry-After, waited %v", elapsed)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetry_RetryAfterTooLong(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDelay time.Duration
+		timeout  time.Duration
+	}{
+		{"beyond the maximum delay", 100 * time.Millisecond, 0},
+		{"beyond the deadline", 10 * time.Second, 200 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := statusServer(t, http.Header{"Retry-After": {"1"}}, http.StatusTooManyRequests, http.StatusOK)
+			client := &http.Client{Transport: NewRetry(nil, WithBackoff(time.Nanosecond, tt.maxDelay))}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+
+			start := time.Now()
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 {
+				t.Errorf("expected the 429 without a retry, got %d after %d attempts", resp.StatusCode, calls.Load())
+			}
+			if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+				t.Errorf("expected no wait, waited %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestRetry_StopsWhenContextDone(t *testing.T) {
+	srv, calls := statusServer(t, nil, http.StatusServiceUnavailable)
+	client := &http.Client{Transport: NewRetry(nil, WithMaxAttempts(10), WithBackoff(time.Second, time.Second))}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+
+	start := time.Now()
+	_, err := client.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context's error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected to stop waiting when the context ended, waited %v", elapsed)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected no retry after the context ended, got %d attempts", calls.Load())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"Mon, 01 Jan 2024 12:00:05 GMT", 5 * time.Second, true},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(resp, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetry_Backoff(t *testing.T) {
+	r := NewRetry(nil, WithBackoff(100*time.Millisecond, time.Second))
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		for range 20 {
+			if d := r.backoff(attempt); d < want/2 || d > want {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, d, want/2, want)
+			}
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
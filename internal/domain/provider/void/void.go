@@ -20,8 +20,13 @@ func (p *Provider) ID() string {
 	return "void"
 }
 
+// Describe reports that this provider supports no queries.
+func (p *Provider) Describe() service.ProviderInfo {
+	return service.ProviderInfo{Name: "Void"}
+}
+
 // Search returns an empty slice of metadata and no error.
-func (p *Provider) Search(_ context.Context, _ string) ([]service.AbsBookMetadata, error) {
+func (p *Provider) Search(_ context.Context, _ service.Query) ([]service.AbsBookMetadata, error) {
 	return []service.AbsBookMetadata{}, nil
 }
 
@@ -4,11 +4,13 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"audiobookshelf-asmr-provider/internal/service"
 )
 
 func TestVoidProvider_Search(t *testing.T) {
 	p := NewProvider()
-	results, err := p.Search(context.Background(), "any query")
+	results, err := p.Search(context.Background(), service.Query{Text: "any query"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
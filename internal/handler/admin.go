@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+// cacheEntryResponse describes a single cached result for the admin API.
+type cacheEntryResponse struct {
+	Key        string                    `json:"key"`
+	Fresh      bool                      `json:"fresh"`
+	Expiry     time.Time                 `json:"expiry"`
+	StaleUntil time.Time                 `json:"staleUntil,omitzero"`
+	Matches    []service.AbsBookMetadata `json:"matches"`
+}
+
+// CacheStats returns the cache usage counters.
+func (h *Handler) CacheStats(w http.ResponseWriter, _ *http.Request) {
+	stats, err := h.service.CacheStats()
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// CacheLookup returns the cached result of a provider for the query given by the
+// same parameters as the search endpoint.
+func (h *Handler) CacheLookup(w http.ResponseWriter, r *http.Request) {
+	query, err := queryFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.IsZero() {
+		http.Error(w, "query parameter 'q', 'query' or 'title' is required", http.StatusBadRequest)
+		return
+	}
+
+	key, entry, found := h.service.CachedEntry(r.PathValue("provider"), query)
+	if !found {
+		http.Error(w, "cache entry not found", http.StatusNotFound)
+		return
+	}
+
+	matches := entry.Data
+	if matches == nil {
+		matches = []service.AbsBookMetadata{}
+	}
+	writeJSON(w, http.StatusOK, cacheEntryResponse{
+		Key:        key,
+		Fresh:      entry.Fresh(time.Now()),
+		Expiry:     entry.Expiry,
+		StaleUntil: entry.StaleUntil,
+		Matches:    matches,
+	})
+}
+
+// CacheDelete removes the cached result of a provider for the query given by the
+// same parameters as the search endpoint, or every cached result of the provider
+// when no query is given.
+func (h *Handler) CacheDelete(w http.ResponseWriter, r *http.Request) {
+	providerID := r.PathValue("provider")
+
+	query, err := queryFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.IsZero() {
+		removed, err := h.service.PurgeProviderCache(providerID)
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		slog.Info("Purged provider cache", "provider", providerID, "removed", removed)
+		writeJSON(w, http.StatusOK, map[string]int{"removed": removed})
+		return
+	}
+
+	deleted, err := h.service.DeleteCachedEntry(providerID, query)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	if !deleted {
+		http.Error(w, "cache entry not found", http.StatusNotFound)
+		return
+	}
+	slog.Info("Deleted cache entry", "provider", providerID, "query", query)
+	writeJSON(w, http.StatusOK, map[string]int{"removed": 1})
+}
+
+// CacheFlush removes every cached result.
+func (h *Handler) CacheFlush(w http.ResponseWriter, _ *http.Request) {
+	removed, err := h.service.FlushCache()
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	slog.Info("Flushed cache", "removed", removed)
+	writeJSON(w, http.StatusOK, map[string]int{"removed": removed})
+}
+
+func writeAdminError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrCacheAdminUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	slog.Error("Admin request failed", "error", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"audiobookshelf-asmr-provider/internal/domain/cache"
+	"audiobookshelf-asmr-provider/internal/service"
+)
+
+// adminCache is an in-memory service.Cache that also implements service.CacheAdmin.
+type adminCache struct {
+	entries map[string]service.CacheEntry
+}
+
+func (c *adminCache) Get(key string) (service.CacheEntry, bool) {
+	e, ok := c.entries[key]
+	return e, ok
+}
+func (c *adminCache) Peek(key string) (service.CacheEntry, bool) { return c.Get(key) }
+func (c *adminCache) Put(key string, entry service.CacheEntry)   { c.entries[key] = entry }
+func (c *adminCache) Stats() service.CacheStats {
+	return service.CacheStats{Entries: len(c.entries)}
+}
+func (c *adminCache) Delete(key string) bool {
+	_, ok := c.entries[key]
+	delete(c.entries, key)
+	return ok
+}
+func (c *adminCache) DeletePrefix(prefix string) int {
+	n := 0
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+			n++
+		}
+	}
+	return n
+}
+func (c *adminCache) Flush() int {
+	n := len(c.entries)
+	c.entries = map[string]service.CacheEntry{}
+	return n
+}
+
+func newAdminMux(cache service.Cache) *http.ServeMux {
+	h := NewHandler(service.NewService(cache))
+	admin := AdminAuth("secret")
+	mux := http.NewServeMux()
+	mux.Handle("GET /admin/cache", admin(http.HandlerFunc(h.CacheStats)))
+	mux.Handle("DELETE /admin/cache", admin(http.HandlerFunc(h.CacheFlush)))
+	mux.Handle("GET /admin/cache/{provider}", admin(http.HandlerFunc(h.CacheLookup)))
+	mux.Handle("DELETE /admin/cache/{provider}", admin(http.HandlerFunc(h.CacheDelete)))
+	return mux
+}
+
+func adminRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	return req
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	mux := newAdminMux(&adminCache{entries: map[string]service.CacheEntry{}})
+
+	for _, auth := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/cache", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected 401, got %d", auth, rec.Code)
+		}
+	}
+}
+
+func TestAdmin_CacheOperations(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	cache := &adminCache{entries: map[string]service.CacheEntry{
+		"dlsite:RJ1": {Data: []service.AbsBookMetadata{{Title: "One"}}, Expiry: expiry, StaleUntil: expiry},
+		"dlsite:RJ2": {Data: []service.AbsBookMetadata{{Title: "Two"}}, Expiry: expiry, StaleUntil: expiry},
+		"all:RJ1":    {Data: []service.AbsBookMetadata{{Title: "One"}}, Expiry: expiry, StaleUntil: expiry},
+		"other:RJ1":  {Data: []service.AbsBookMetadata{{Title: "One"}}, Expiry: expiry, StaleUntil: expiry},
+	}}
+	mux := newAdminMux(cache)
+
+	// Stats
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/cache"))
+	var stats service.CacheStats
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil || stats.Entries != 4 {
+		t.Fatalf("unexpected stats response (%d): %+v, err=%v", rec.Code, stats, err)
+	}
+
+	// Lookup
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/cache/dlsite?q=RJ1"))
+	var entry cacheEntryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&entry); err != nil {
+		t.Fatalf("failed to decode lookup response: %v", err)
+	}
+	if entry.Key != "dlsite:RJ1" || !entry.Fresh || len(entry.Matches) != 1 {
+		t.Errorf("unexpected lookup response: %+v", entry)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/cache/dlsite?q=missing"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing entry, got %d", rec.Code)
+	}
+
+	// Delete single key
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/cache/dlsite?q=RJ1"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 deleting entry, got %d", rec.Code)
+	}
+	if _, ok := cache.entries["dlsite:RJ1"]; ok {
+		t.Error("expected entry to be deleted")
+	}
+
+	// Purge provider, along with the aggregated results that may include it
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/cache/dlsite"))
+	var purged map[string]int
+	_ = json.NewDecoder(rec.Body).Decode(&purged)
+	if _, ok := cache.entries["other:RJ1"]; purged["removed"] != 2 || len(cache.entries) != 1 || !ok {
+		t.Errorf("expected only the other provider's entry to remain, got %v (removed %v)", cache.entries, purged)
+	}
+
+	// Flush
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/cache"))
+	var flushed map[string]int
+	_ = json.NewDecoder(rec.Body).Decode(&flushed)
+	if flushed["removed"] != 1 || len(cache.entries) != 0 {
+		t.Errorf("unexpected flush result: %v, remaining=%d", flushed, len(cache.entries))
+	}
+}
+
+func TestAdmin_CacheLookupLeavesStatsUntouched(t *testing.T) {
+	memCache := cache.NewMemoryCache()
+	memCache.Put("dlsite:RJ1", service.CacheEntry{Data: []service.AbsBookMetadata{{Title: "A"}}, Expiry: time.Now().Add(time.Hour)})
+	mux := newAdminMux(memCache)
+
+	for _, q := range []string{"RJ1", "missing"} {
+		mux.ServeHTTP(httptest.NewRecorder(), adminRequest(http.MethodGet, "/admin/cache/dlsite?q="+q))
+	}
+	if stats := memCache.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("expected lookups not to count as hits or misses, got %+v", stats)
+	}
+}
+
+func TestAdmin_UnsupportedCache(t *testing.T) {
+	mux := newAdminMux(&mockCache{})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/cache"))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501 for cache without admin support, got %d", rec.Code)
+	}
+}
@@ -2,8 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"audiobookshelf-asmr-provider/internal/service"
 )
@@ -28,25 +34,88 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	h._Search(w, r, r.PathValue("provider"))
 }
 
+// providersResponse is the body of GET /api/providers.
+type providersResponse struct {
+	Providers []service.ProviderInfo `json:"providers"`
+}
+
+// Providers lists the registered providers and their capabilities.
+func (h *Handler) Providers(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(providersResponse{Providers: h.service.ProviderInfos()})
+}
+
+// Health reports the health of the service and its providers. It responds 200 as
+// long as the server runs; failing providers are reported as a degraded status.
+func (h *Handler) Health(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.service.Health())
+}
+
+// GetWork handles lookups of a single work by provider and ID.
+func (h *Handler) GetWork(w http.ResponseWriter, r *http.Request) {
+	providerID := r.PathValue("provider")
+	id := r.PathValue("id")
+
+	slog.Debug("Work request", "provider", providerID, "id", id)
+
+	work, err := h.service.GetWork(r.Context(), providerID, id)
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrWorkLookupUnsupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	case errors.Is(err, service.ErrCircuitOpen):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case errors.Is(err, service.ErrAccessDenied):
+		slog.Warn("Work lookup denied by upstream", "provider", providerID, "id", id, "error", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	case err != nil:
+		slog.Error("Work lookup failed", "provider", providerID, "id", id, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(work)
+}
+
 // _Search is a shared helper for executing searches.
 func (h *Handler) _Search(w http.ResponseWriter, r *http.Request, providerID string) {
 	if providerID == "" {
 		providerID = "all"
 	}
 
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		query = r.URL.Query().Get("query")
+	query, err := queryFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-
-	if query == "" {
-		http.Error(w, "query parameter 'q' or 'query' is required", http.StatusBadRequest)
+	if query.IsZero() {
+		http.Error(w, "query parameter 'q', 'query' or 'title' is required", http.StatusBadRequest)
 		return
 	}
 
 	slog.Debug("Search request", "provider", providerID, "query", query, "url_params", r.URL.Query())
 
 	resp, err := h.service.SearchByProviderID(r.Context(), providerID, query)
+	if errors.Is(err, service.ErrUnsupportedFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrCircuitOpen) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, service.ErrAccessDenied) {
+		slog.Warn("Search denied by upstream", "provider", providerID, "error", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
 	if err != nil {
 		slog.Error("Search failed", "provider", providerID, "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -55,6 +124,124 @@ func (h *Handler) _Search(w http.ResponseWriter, r *http.Request, providerID str
 
 	slog.Debug("Search response", "provider", providerID, "response", resp)
 
+	if resp.CacheStatus != "" {
+		w.Header().Set("X-Cache", string(resp.CacheStatus))
+	}
+	if len(resp.Sources) > 0 {
+		w.Header().Set("X-Provider-Status", formatSources(resp.Sources))
+	}
+
+	if !debugRequested(r) {
+		resp.Matches = withoutScores(resp.Matches)
+		resp.Sources = nil
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+// queryFromRequest builds a structured query from the Audiobookshelf search parameters
+// and the optional "limit" and "page" paging parameters.
+func queryFromRequest(r *http.Request) (service.Query, error) {
+	params := r.URL.Query()
+
+	text := params.Get("q")
+	if text == "" {
+		text = params.Get("query")
+	}
+
+	limit, err := positiveIntParam(params.Get("limit"))
+	if err != nil {
+		return service.Query{}, fmt.Errorf("query parameter 'limit' %w", err)
+	}
+	if limit > service.MaxSearchLimit {
+		return service.Query{}, fmt.Errorf("query parameter 'limit' must not exceed %d", service.MaxSearchLimit)
+	}
+	page, err := positiveIntParam(params.Get("page"))
+	if err != nil {
+		return service.Query{}, fmt.Errorf("query parameter 'page' %w", err)
+	}
+	filters, err := filtersFromParams(params)
+	if err != nil {
+		return service.Query{}, err
+	}
+
+	return service.Query{
+		Text:   strings.TrimSpace(text),
+		Title:  strings.TrimSpace(params.Get("title")),
+		Author: strings.TrimSpace(params.Get("author")),
+
+		Language: strings.TrimSpace(params.Get("lang")),
+
+		Limit: limit,
+		Page:  page,
+
+		Filters: filters,
+	}, nil
+}
+
+// filtersFromParams reads the search filter parameters.
+func filtersFromParams(params url.Values) (service.Filters, error) {
+	get := func(f service.Filter) string { return strings.TrimSpace(params.Get(string(f))) }
+
+	filters := service.Filters{
+		Age:            strings.ToLower(get(service.FilterAge)),
+		VoiceActor:     get(service.FilterVoiceActor),
+		Maker:          get(service.FilterMaker),
+		Genre:          get(service.FilterGenre),
+		ReleasedAfter:  get(service.FilterReleasedAfter),
+		ReleasedBefore: get(service.FilterReleasedBefore),
+	}
+
+	switch filters.Age {
+	case "", service.AgeAll, service.AgeR15, service.AgeR18:
+	default:
+		return service.Filters{}, fmt.Errorf("query parameter 'age' must be one of %q, %q or %q", service.AgeAll, service.AgeR15, service.AgeR18)
+	}
+	for _, f := range []service.Filter{service.FilterReleasedAfter, service.FilterReleasedBefore} {
+		if raw := get(f); raw != "" {
+			if _, err := time.Parse(time.DateOnly, raw); err != nil {
+				return service.Filters{}, fmt.Errorf("query parameter '%s' must be a date (YYYY-MM-DD)", f)
+			}
+		}
+	}
+	return filters, nil
+}
+
+// debugRequested reports whether the client asked for debugging fields with "debug=1".
+func debugRequested(r *http.Request) bool {
+	debug, _ := strconv.ParseBool(r.URL.Query().Get("debug"))
+	return debug
+}
+
+// withoutScores returns a copy of matches with the relevance scores cleared.
+// The matches may be shared with the cache, so they are not modified in place.
+func withoutScores(matches []service.AbsBookMetadata) []service.AbsBookMetadata {
+	stripped := make([]service.AbsBookMetadata, len(matches))
+	for i, m := range matches {
+		m.Score = 0
+		stripped[i] = m
+	}
+	return stripped
+}
+
+// formatSources renders per-provider search outcomes for the X-Provider-Status
+// header, e.g. "dlsite=ok, other=timeout".
+func formatSources(sources []service.SourceStatus) string {
+	parts := make([]string, len(sources))
+	for i, src := range sources {
+		parts[i] = src.Provider + "=" + string(src.State)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// positiveIntParam parses an optional positive integer parameter; empty yields 0.
+func positiveIntParam(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 1 {
+		return 0, errors.New("must be a positive integer")
+	}
+	return v, nil
+}
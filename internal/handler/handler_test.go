@@ -4,30 +4,34 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"audiobookshelf-asmr-provider/internal/domain/provider/all"
 	"audiobookshelf-asmr-provider/internal/service"
 )
 
 // mockCache implements service.Cache for testing.
 type mockCache struct{}
 
-func (m *mockCache) Get(_ string) ([]service.AbsBookMetadata, bool)             { return nil, false }
-func (m *mockCache) Put(_ string, _ []service.AbsBookMetadata, _ time.Duration) {}
+func (m *mockCache) Get(_ string) (service.CacheEntry, bool) { return service.CacheEntry{}, false }
+func (m *mockCache) Put(_ string, _ service.CacheEntry)      {}
 
 // mockProvider implements service.Provider for testing.
 type mockProvider struct {
 	id      string
 	results []service.AbsBookMetadata
 	err     error
+	query   service.Query
 }
 
 func (m *mockProvider) ID() string              { return m.id }
 func (m *mockProvider) CacheTTL() time.Duration { return 1 * time.Hour }
-func (m *mockProvider) Search(_ context.Context, _ string) ([]service.AbsBookMetadata, error) {
+func (m *mockProvider) Search(_ context.Context, query service.Query) ([]service.AbsBookMetadata, error) {
+	m.query = query
 	return m.results, m.err
 }
 
@@ -81,6 +85,151 @@ func TestSearch_WithQueryFallbackParam(t *testing.T) {
 	}
 }
 
+func TestSearch_TitleAndAuthorParams(t *testing.T) {
+	mock := &mockProvider{id: "all"}
+	svc := service.NewService(&mockCache{}, mock)
+	h := NewHandler(svc)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/search", h.Search)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?title=My+Title&author=+Circle+&lang=en", nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	want := service.Query{Title: "My Title", Author: "Circle", Language: "en"}
+	if mock.query != want {
+		t.Errorf("expected query %+v, got %+v", want, mock.query)
+	}
+}
+
+func TestSearch_Paging(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   string
+		wantCode int
+		wantPage [2]int // limit, page
+	}{
+		{"defaults", "", http.StatusOK, [2]int{0, 0}},
+		{"limit and page", "&limit=20&page=3", http.StatusOK, [2]int{20, 3}},
+		{"limit too large", "&limit=51", http.StatusBadRequest, [2]int{}},
+		{"zero page", "&page=0", http.StatusBadRequest, [2]int{}},
+		{"not a number", "&limit=ten", http.StatusBadRequest, [2]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProvider{id: "all"}
+			h := NewHandler(service.NewService(&mockCache{}, mock))
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/search", h.Search)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/search?q=test"+tt.params, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if got := [2]int{mock.query.Limit, mock.query.Page}; tt.wantCode == http.StatusOK && got != tt.wantPage {
+				t.Errorf("expected limit/page %v, got %v", tt.wantPage, got)
+			}
+		})
+	}
+}
+
+func TestSearch_Filters(t *testing.T) {
+	mock := &mockProvider{id: "all"}
+	h := NewHandler(service.NewService(&mockCache{}, mock))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/search", h.Search)
+
+	tests := []struct {
+		name     string
+		params   string
+		wantCode int
+	}{
+		{"invalid age", "?q=test&age=adult", http.StatusBadRequest},
+		{"invalid date", "?q=test&released_after=2024/01/01", http.StatusBadRequest},
+		// The mock provider declares no filters.
+		{"unsupported filter", "?q=test&cv=Name", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/search"+tt.params, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestQueryFromRequest_Filters(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/search?age=R18&cv=Voice&maker=Circle&genre=497&released_after=2024-01-01&released_before=2024-12-31", nil)
+
+	query, err := queryFromRequest(req)
+	if err != nil {
+		t.Fatalf("queryFromRequest failed: %v", err)
+	}
+	want := service.Filters{
+		Age:            service.AgeR18,
+		VoiceActor:     "Voice",
+		Maker:          "Circle",
+		Genre:          "497",
+		ReleasedAfter:  "2024-01-01",
+		ReleasedBefore: "2024-12-31",
+	}
+	if query.Filters != want {
+		t.Errorf("expected filters %+v, got %+v", want, query.Filters)
+	}
+	if query.IsZero() {
+		t.Error("expected a filter-only query to be searchable")
+	}
+}
+
+func TestSearch_DebugScore(t *testing.T) {
+	mock := &mockProvider{id: "all", results: []service.AbsBookMetadata{{Title: "Scored", Score: 4.5}}}
+	h := NewHandler(service.NewService(&mockCache{}, mock))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/search", h.Search)
+
+	for _, tt := range []struct {
+		params string
+		want   float64
+	}{
+		{"?q=test", 0},
+		{"?q=test&debug=1", 4.5},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/search"+tt.params, nil)
+		rec := httptest.NewRecorder()
+
+		mux.ServeHTTP(rec, req)
+
+		var resp service.AbsMetadataResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Matches) != 1 || resp.Matches[0].Score != tt.want {
+			t.Errorf("%s: expected score %v, got %+v", tt.params, tt.want, resp.Matches)
+		}
+	}
+	if mock.results[0].Score != 4.5 {
+		t.Error("expected provider results not to be modified")
+	}
+}
+
 func TestSearch_MissingQuery(t *testing.T) {
 	svc := service.NewService(&mockCache{})
 	h := NewHandler(svc)
@@ -170,3 +319,217 @@ func TestSearchSingle_UnknownProvider(t *testing.T) {
 		t.Errorf("expected 0 matches, got %d", len(resp.Matches))
 	}
 }
+
+func TestSearch_CacheStatusHeader(t *testing.T) {
+	mock := &mockProvider{
+		id:      "dlsite",
+		results: []service.AbsBookMetadata{{Title: "Result"}},
+	}
+	svc := service.NewService(&mockCache{}, mock)
+	h := NewHandler(svc)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/{provider}/search", h.Search)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dlsite/search?q=test", nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Cache"); got != string(service.CacheMiss) {
+		t.Errorf("expected X-Cache %q, got %q", service.CacheMiss, got)
+	}
+}
+
+// mockWorkProvider is a mockProvider that also supports work lookups.
+type mockWorkProvider struct {
+	mockProvider
+	work *service.WorkDetail
+	err  error
+}
+
+func (m *mockWorkProvider) GetWork(_ context.Context, _ string) (*service.WorkDetail, error) {
+	return m.work, m.err
+}
+
+func TestGetWork(t *testing.T) {
+	work := &service.WorkDetail{
+		AbsBookMetadata: service.AbsBookMetadata{Title: "Detail"},
+		ID:              "RJ123456",
+		Price:           990,
+	}
+	tests := []struct {
+		name     string
+		provider service.Provider
+		path     string
+		want     int
+	}{
+		{"found", &mockWorkProvider{mockProvider: mockProvider{id: "dlsite"}, work: work}, "/api/dlsite/works/RJ123456", http.StatusOK},
+		{"not found", &mockWorkProvider{mockProvider: mockProvider{id: "dlsite"}, err: service.ErrNotFound}, "/api/dlsite/works/RJ000000", http.StatusNotFound},
+		{"unknown provider", &mockWorkProvider{mockProvider: mockProvider{id: "dlsite"}}, "/api/unknown/works/RJ123456", http.StatusNotFound},
+		{"unsupported", &mockProvider{id: "dlsite"}, "/api/dlsite/works/RJ123456", http.StatusNotImplemented},
+		{"provider error", &mockWorkProvider{mockProvider: mockProvider{id: "dlsite"}, err: errors.New("boom")}, "/api/dlsite/works/RJ123456", http.StatusInternalServerError},
+		{"access denied", &mockWorkProvider{mockProvider: mockProvider{id: "dlsite"}, err: fmt.Errorf("age gate: %w", service.ErrAccessDenied)}, "/api/dlsite/works/RJ123456", http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(service.NewService(&mockCache{}, tt.provider))
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/{provider}/works/{id}", h.GetWork)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var got map[string]any
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if got["title"] != "Detail" || got["id"] != "RJ123456" || got["price"] != float64(990) {
+				t.Errorf("unexpected response body: %v", got)
+			}
+		})
+	}
+}
+
+func TestProviders(t *testing.T) {
+	svc := service.NewService(&mockCache{}, &mockProvider{id: "dlsite"}, &mockProvider{id: "all"})
+	h := NewHandler(svc)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/providers", h.Providers)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/providers", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var resp providersResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Providers) != 2 || resp.Providers[0].ID != "dlsite" || resp.Providers[0].CacheTTLSeconds != 3600 {
+		t.Errorf("unexpected providers: %+v", resp.Providers)
+	}
+}
+
+// reportingProvider is a mockProvider that reports per-source outcomes.
+type reportingProvider struct {
+	mockProvider
+	sources []service.SourceStatus
+}
+
+func (r *reportingProvider) SearchWithSources(ctx context.Context, query service.Query) ([]service.AbsBookMetadata, []service.SourceStatus, error) {
+	matches, err := r.Search(ctx, query)
+	return matches, r.sources, err
+}
+
+func TestSearch_ProviderStatus(t *testing.T) {
+	mock := &reportingProvider{
+		mockProvider: mockProvider{id: "all", results: []service.AbsBookMetadata{{Title: "Partial"}}},
+		sources: []service.SourceStatus{
+			{Provider: "dlsite", State: service.SourceOK, Count: 1},
+			{Provider: "other", State: service.SourceFailed, Error: "boom"},
+		},
+	}
+	h := NewHandler(service.NewService(&mockCache{}, mock))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/search", h.SearchAll)
+
+	for _, tt := range []struct {
+		params      string
+		wantSources int
+	}{
+		{"?q=test", 0},
+		{"?q=test&debug=1", 2},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/search"+tt.params, nil)
+		rec := httptest.NewRecorder()
+
+		mux.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("X-Provider-Status"); got != "dlsite=ok, other=failed" {
+			t.Errorf("%s: unexpected X-Provider-Status %q", tt.params, got)
+		}
+		var resp service.AbsMetadataResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Sources) != tt.wantSources {
+			t.Errorf("%s: expected %d sources in the body, got %+v", tt.params, tt.wantSources, resp.Sources)
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	h := NewHandler(service.NewService(&mockCache{}, &mockProvider{id: "dlsite"}))
+
+	rec := httptest.NewRecorder()
+	h.Health(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var report service.HealthReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Status != service.HealthOK || len(report.Providers) != 1 || report.Providers[0].ID != "dlsite" {
+		t.Errorf("unexpected health report: %+v", report)
+	}
+}
+
+func TestSearchAll_CircuitOpen(t *testing.T) {
+	mock := &mockProvider{id: "dlsite", err: fmt.Errorf("dlsite: %w", service.ErrCircuitOpen)}
+	h := NewHandler(service.NewService(&mockCache{}, mock, all.NewProvider(mock)))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=test", nil)
+	rec := httptest.NewRecorder()
+	h.SearchAll(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while every provider's circuit is open, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSearch_CircuitOpen(t *testing.T) {
+	mock := &mockProvider{id: "dlsite", err: fmt.Errorf("dlsite: %w", service.ErrCircuitOpen)}
+	h := NewHandler(service.NewService(&mockCache{}, mock))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/{provider}/search", h.Search)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dlsite/search?q=test", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while the circuit is open, got %d", rec.Code)
+	}
+}
+
+func TestSearch_AccessDenied(t *testing.T) {
+	mock := &mockProvider{id: "dlsite", err: fmt.Errorf("age gate: %w", service.ErrAccessDenied)}
+	h := NewHandler(service.NewService(&mockCache{}, mock))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/{provider}/search", h.Search)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dlsite/search?q=test", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502 when the upstream denies access, got %d", rec.Code)
+	}
+}
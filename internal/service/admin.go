@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+)
+
+// ErrCacheAdminUnsupported is returned when the configured cache cannot be inspected or purged.
+var ErrCacheAdminUnsupported = errors.New("cache does not support administration")
+
+// CacheStats is a snapshot of cache usage counters. Evictions counts the entries the
+// cache removed by itself, to stay within its limits or because they expired;
+// entries deleted through CacheAdmin are not counted.
+type CacheStats struct {
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+// CacheAdmin is implemented by caches that support inspection and invalidation.
+type CacheAdmin interface {
+	// Stats returns a snapshot of the cache usage counters.
+	Stats() CacheStats
+	// Peek returns the usable entry stored under key like Cache.Get, but leaves the
+	// usage counters and the eviction order untouched.
+	Peek(key string) (CacheEntry, bool)
+	// Delete removes a single key and reports whether it existed.
+	Delete(key string) bool
+	// DeletePrefix removes all keys starting with prefix and returns how many were removed.
+	DeletePrefix(prefix string) int
+	// Flush removes every entry and returns how many were removed.
+	Flush() int
+}
+
+// cacheKeyFor builds the key under which a provider's result for a query key is cached.
+func cacheKeyFor(providerID, queryKey string) string {
+	return providerID + ":" + queryKey
+}
+
+func (s *Service) cacheAdmin() (CacheAdmin, error) {
+	admin, ok := s.cache.(CacheAdmin)
+	if !ok {
+		return nil, ErrCacheAdminUnsupported
+	}
+	return admin, nil
+}
+
+// CacheStats returns usage counters of the underlying cache.
+func (s *Service) CacheStats() (CacheStats, error) {
+	admin, err := s.cacheAdmin()
+	if err != nil {
+		return CacheStats{}, err
+	}
+	return admin.Stats(), nil
+}
+
+// CachedEntry returns the cached result of a provider for query, if any.
+func (s *Service) CachedEntry(providerID string, query Query) (key string, entry CacheEntry, found bool) {
+	key = cacheKeyFor(providerID, query.CacheKey())
+	if admin, ok := s.cache.(CacheAdmin); ok {
+		entry, found = admin.Peek(key)
+	} else {
+		entry, found = s.cache.Get(key)
+	}
+	return key, entry, found
+}
+
+// DeleteCachedEntry removes the cached result of a provider for query.
+func (s *Service) DeleteCachedEntry(providerID string, query Query) (bool, error) {
+	admin, err := s.cacheAdmin()
+	if err != nil {
+		return false, err
+	}
+	return admin.Delete(cacheKeyFor(providerID, query.CacheKey())), nil
+}
+
+// PurgeProviderCache removes every cached result of a provider, together with the
+// aggregated results of the "all" provider, which may contain stale records of it.
+func (s *Service) PurgeProviderCache(providerID string) (int, error) {
+	admin, err := s.cacheAdmin()
+	if err != nil {
+		return 0, err
+	}
+	removed := admin.DeletePrefix(cacheKeyFor(providerID, ""))
+	if providerID != "all" {
+		removed += admin.DeletePrefix(cacheKeyFor("all", ""))
+	}
+	return removed, nil
+}
+
+// FlushCache removes every cached result.
+func (s *Service) FlushCache() (int, error) {
+	admin, err := s.cacheAdmin()
+	if err != nil {
+		return 0, err
+	}
+	return admin.Flush(), nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// flightCall is an in-progress upstream fetch shared by one or more callers.
+type flightCall struct {
+	done    chan struct{}
+	result  CacheEntry
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// flightGroup collapses concurrent fetches for the same key into a single call.
+// Unlike a plain singleflight, the shared call runs on its own context and is only
+// cancelled once every waiting caller has given up.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: make(map[string]*flightCall)}
+}
+
+// Do executes fn for key unless an identical call is already in flight, in which case
+// it waits for that call's result. The result is the entry fetched for key; shared
+// reports whether it was produced by a call started by another caller.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(context.Context) (CacheEntry, error)) (result CacheEntry, shared bool, err error) {
+	g.mu.Lock()
+	c, shared := g.calls[key]
+	if !shared {
+		// Detach from the first caller's cancellation: other callers may still be waiting.
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(fctx, key, c, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.result, shared, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// Nobody is interested any more; abort the upstream fetch.
+			c.cancel()
+			g.forget(key, c)
+		}
+		g.mu.Unlock()
+		return CacheEntry{}, shared, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, c *flightCall, fn func(context.Context) (CacheEntry, error)) {
+	defer c.cancel()
+	c.result, c.err = fn(ctx)
+
+	g.mu.Lock()
+	g.forget(key, c)
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// forget removes c from the in-flight set if it is still the current call for key.
+// The caller must hold g.mu.
+func (g *flightGroup) forget(key string, c *flightCall) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// unhealthyAfterFailures is the number of consecutive failed fetches after which a provider is reported unhealthy.
+const unhealthyAfterFailures = 3
+
+// healthTracker records consecutive upstream failures per provider.
+type healthTracker struct {
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{failures: make(map[string]int)}
+}
+
+// record updates the failure streak of a provider after a fetch.
+// Not-found results and cancelled requests say nothing about upstream health.
+func (h *healthTracker) record(providerID string, err error) {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		delete(h.failures, providerID)
+		return
+	}
+	h.failures[providerID]++
+}
+
+// healthy reports whether the provider's failure streak is below the threshold.
+func (h *healthTracker) healthy(providerID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.failures[providerID] < unhealthyAfterFailures
+}
+
+// HealthStatus summarises the health of the service.
+type HealthStatus string
+
+const (
+	// HealthOK means every provider is healthy.
+	HealthOK HealthStatus = "ok"
+	// HealthDegraded means the service works, but some providers are failing or
+	// their parsers no longer recognise the upstream pages.
+	HealthDegraded HealthStatus = "degraded"
+)
+
+// HealthReport is the health of the service and its providers.
+type HealthReport struct {
+	Status    HealthStatus     `json:"status"`
+	Providers []ProviderHealth `json:"providers"`
+}
+
+// ProviderHealth is the health of a single provider.
+type ProviderHealth struct {
+	ID      string `json:"id"`
+	Healthy bool   `json:"healthy"`
+	// Circuit is the state of the provider's circuit breaker, if it has one.
+	Circuit CircuitState `json:"circuit,omitempty"`
+	// Parser is the state of the provider's page parser, if it scrapes pages.
+	Parser ParserState `json:"parser,omitempty"`
+}
+
+// Health reports the health of every registered provider.
+func (s *Service) Health() HealthReport {
+	report := HealthReport{Status: HealthOK, Providers: make([]ProviderHealth, 0, len(s.providers))}
+	for _, p := range s.providers {
+		ph := ProviderHealth{ID: p.ID(), Healthy: s.providerHealthy(p)}
+		if c, ok := p.(CircuitReporter); ok {
+			ph.Circuit = c.CircuitState()
+		}
+		if r, ok := p.(ParserReporter); ok {
+			ph.Parser = r.ParserStats().State
+		}
+		if !ph.Healthy || ph.Parser == ParserDegraded {
+			report.Status = HealthDegraded
+		}
+		report.Providers = append(report.Providers, ph)
+	}
+	return report
+}
+
+// providerHealthy reports whether p is neither failing repeatedly nor cut off by
+// an open circuit breaker.
+func (s *Service) providerHealthy(p Provider) bool {
+	if c, ok := p.(CircuitReporter); ok && c.CircuitState() == CircuitOpen {
+		return false
+	}
+	return s.health.healthy(p.ID())
+}
+
+// ParserStats returns the parser validation counters of every provider that
+// scrapes pages, keyed by provider ID.
+func (s *Service) ParserStats() map[string]ParserStats {
+	stats := make(map[string]ParserStats)
+	for _, p := range s.providers {
+		if r, ok := p.(ParserReporter); ok {
+			if ps := r.ParserStats(); ps.State != "" {
+				stats[p.ID()] = ps
+			}
+		}
+	}
+	return stats
+}
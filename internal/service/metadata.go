@@ -2,27 +2,78 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 )
 
 // Cache defines the interface for a metadata cache.
 type Cache interface {
-	Get(key string) ([]AbsBookMetadata, bool)
-	Put(key string, data []AbsBookMetadata, ttl time.Duration)
+	// Get returns the entry for key if it is still usable (fresh or within its stale window).
+	Get(key string) (CacheEntry, bool)
+	// Put stores an entry, retaining it until entry.StaleUntil.
+	Put(key string, entry CacheEntry)
+}
+
+const (
+	defaultCacheTTL             = 1 * time.Hour
+	defaultStaleWhileRevalidate = 1 * time.Hour
+	defaultStaleIfError         = 7 * 24 * time.Hour
+	defaultNegativeTTL          = 15 * time.Minute
+	backgroundRefreshTimeout    = 30 * time.Second
+)
+
+// Options tunes the caching behaviour of a Service.
+// Zero values select the defaults; negative values disable the feature.
+type Options struct {
+	// StaleWhileRevalidate is how long after expiry an entry is served immediately
+	// while a fresh copy is fetched in the background.
+	StaleWhileRevalidate time.Duration
+	// StaleIfError is how long after expiry an entry may be served when the
+	// provider fails to return a fresh result.
+	StaleIfError time.Duration
+	// NegativeTTL is how long empty and not-found results are cached.
+	// It is capped at the provider's regular TTL.
+	NegativeTTL time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.StaleWhileRevalidate == 0 {
+		o.StaleWhileRevalidate = defaultStaleWhileRevalidate
+	}
+	if o.StaleIfError == 0 {
+		o.StaleIfError = defaultStaleIfError
+	}
+	if o.NegativeTTL == 0 {
+		o.NegativeTTL = defaultNegativeTTL
+	}
+	return o
 }
 
 // Service orchestrates metadata fetching from multiple providers with caching support.
 type Service struct {
 	providers []Provider
 	cache     Cache
+	flights   *flightGroup
+	health    *healthTracker
+	opts      Options
 }
 
 // NewService creates a new metadata service with the given providers and cache implementation.
 func NewService(cache Cache, providers ...Provider) *Service {
+	return NewServiceWithOptions(cache, Options{}, providers...)
+}
+
+// NewServiceWithOptions creates a new metadata service with custom caching options.
+func NewServiceWithOptions(cache Cache, opts Options, providers ...Provider) *Service {
 	return &Service{
 		providers: providers,
 		cache:     cache,
+		flights:   newFlightGroup(),
+		health:    newHealthTracker(),
+		opts:      opts.withDefaults(),
 	}
 }
 
@@ -31,29 +82,74 @@ func (s *Service) Providers() []Provider {
 	return s.providers
 }
 
+// ProviderInfos describes every registered provider, including its current health.
+func (s *Service) ProviderInfos() []ProviderInfo {
+	infos := make([]ProviderInfo, 0, len(s.providers))
+	for _, p := range s.providers {
+		info := ProviderInfo{Name: p.ID()}
+		if d, ok := p.(Describer); ok {
+			info = d.Describe()
+		}
+		if info.QueryKinds == nil {
+			info.QueryKinds = []QueryKind{}
+		}
+		if info.Filters == nil {
+			info.Filters = []Filter{}
+		}
+		info.ID = p.ID()
+		info.CacheTTLSeconds = int64(p.CacheTTL().Seconds())
+		info.Healthy = s.providerHealthy(p)
+		infos = append(infos, info)
+	}
+	return infos
+}
+
 // Search queries all registered providers by delegating to the "all" provider.
-func (s *Service) Search(ctx context.Context, query string) (*AbsMetadataResponse, error) {
+func (s *Service) Search(ctx context.Context, query Query) (*AbsMetadataResponse, error) {
 	return s.SearchByProviderID(ctx, "all", query)
 }
 
 // SearchByProviderID queries a specific provider by its ID.
-func (s *Service) SearchByProviderID(ctx context.Context, providerID, query string) (*AbsMetadataResponse, error) {
+func (s *Service) SearchByProviderID(ctx context.Context, providerID string, query Query) (*AbsMetadataResponse, error) {
 	p := s.getProvider(providerID)
 	if p == nil {
 		// Provider not found, return valid empty result (void behavior)
 		return &AbsMetadataResponse{Matches: []AbsBookMetadata{}}, nil
 	}
 
-	matches, err := s.searchProviderWithCache(ctx, p, query)
+	if unsupported := UnsupportedFilters(p, query.Filters); len(unsupported) > 0 {
+		return nil, fmt.Errorf("%w: %s does not support %v", ErrUnsupportedFilter, p.ID(), unsupported)
+	}
+
+	entry, status, err := s.searchProviderWithCache(ctx, p, query)
 	if err != nil {
 		return nil, err
 	}
 
+	matches := entry.Data
 	if matches == nil {
 		matches = []AbsBookMetadata{}
 	}
-	return &AbsMetadataResponse{Matches: matches}, nil
+	return &AbsMetadataResponse{Matches: matches, CacheStatus: status, Sources: sourcesFor(entry, status)}, nil
+
+}
+
+// GetWork looks up a single work by ID on a specific provider.
+func (s *Service) GetWork(ctx context.Context, providerID, id string) (*WorkDetail, error) {
+	p := s.getProvider(providerID)
+	if p == nil {
+		return nil, fmt.Errorf("unknown provider %q: %w", providerID, ErrNotFound)
+	}
+
+	fetcher, ok := p.(WorkFetcher)
+	if !ok {
+		return nil, ErrWorkLookupUnsupported
+	}
 
+	slog.Debug("Fetching work from provider", "provider", providerID, "id", id)
+	work, err := fetcher.GetWork(ctx, id)
+	s.health.record(providerID, err)
+	return work, err
 }
 
 // getProvider helper to find a provider by ID. If not found, returns nil.
@@ -67,21 +163,83 @@ func (s *Service) getProvider(id string) Provider {
 }
 
 // searchProviderWithCache handles the caching logic for provider searches.
-func (s *Service) searchProviderWithCache(ctx context.Context, p Provider, query string) ([]AbsBookMetadata, error) {
-	cacheKey := p.ID() + ":" + query
+// Concurrent cache misses for the same provider and query share a single upstream fetch.
+//
+// Expired entries are handled in two stages: shortly after expiry they are served
+// immediately while a refresh runs in the background; after that they are only
+// served if fetching a fresh result fails.
+func (s *Service) searchProviderWithCache(ctx context.Context, p Provider, query Query) (CacheEntry, CacheStatus, error) {
+	cacheKey := cacheKeyFor(p.ID(), query.CacheKey())
 
 	// Check Cache
-	if data, ok := s.cache.Get(cacheKey); ok {
+	entry, found := s.cache.Get(cacheKey)
+	now := time.Now()
+	if found && entry.Fresh(now) {
 		slog.Debug("Cache hit", "provider", p.ID(), "query", query)
-		return data, nil
+		return entry, CacheHit, nil
+	}
+	if found && now.Before(entry.Expiry.Add(s.opts.StaleWhileRevalidate)) {
+		slog.Debug("Serving stale cache entry while revalidating", "provider", p.ID(), "query", query)
+		s.refreshInBackground(ctx, p, query, cacheKey)
+		return entry, CacheStale, nil
 	}
 
+	fetched, shared, err := s.flights.Do(ctx, cacheKey, func(ctx context.Context) (CacheEntry, error) {
+		return s.fetchAndStore(ctx, p, query, cacheKey)
+	})
+	if shared {
+		slog.Debug("Joined in-flight provider fetch", "provider", p.ID(), "query", query)
+	}
+	if err != nil {
+		if found && ctx.Err() == nil && now.Before(entry.Expiry.Add(s.opts.StaleIfError)) {
+			slog.Warn("Provider failed, serving stale cache entry", "provider", p.ID(), "query", query, "error", err)
+			return entry, CacheStale, nil
+		}
+		return CacheEntry{}, CacheMiss, err
+	}
+	return fetched, CacheMiss, nil
+}
+
+// refreshInBackground re-fetches an entry without blocking the caller.
+// Concurrent refreshes of the same key are coalesced with regular lookups.
+func (s *Service) refreshInBackground(ctx context.Context, p Provider, query Query, cacheKey string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backgroundRefreshTimeout)
+		defer cancel()
+		_, _, err := s.flights.Do(ctx, cacheKey, func(ctx context.Context) (CacheEntry, error) {
+			return s.fetchAndStore(ctx, p, query, cacheKey)
+		})
+		if err != nil {
+			slog.Warn("Background cache refresh failed", "provider", p.ID(), "query", query, "error", err)
+		}
+	}()
+}
+
+// fetchAndStore queries the provider and caches a successful result.
+// Not-found errors are treated as an empty result so that they are cached too,
+// but empty results are kept for the shorter negative TTL, as are aggregated
+// results that miss a failed provider's part.
+func (s *Service) fetchAndStore(ctx context.Context, p Provider, query Query, cacheKey string) (CacheEntry, error) {
 	slog.Debug("Fetching from provider", "provider", p.ID(), "query", query)
 
 	// Fetch from Provider
-	matches, err := p.Search(ctx, query)
+	var (
+		matches []AbsBookMetadata
+		sources []SourceStatus
+		err     error
+	)
+	if r, ok := p.(SourceReporter); ok {
+		matches, sources, err = r.SearchWithSources(ctx, query)
+	} else {
+		matches, err = p.Search(ctx, query)
+	}
+	s.health.record(p.ID(), err)
+	if errors.Is(err, ErrNotFound) {
+		slog.Debug("Provider reported not found", "provider", p.ID(), "query", query, "error", err)
+		matches, err = []AbsBookMetadata{}, nil
+	}
 	if err != nil {
-		return nil, err
+		return CacheEntry{}, err
 	}
 
 	slog.Debug("Provider response", "provider", p.ID(), "count", len(matches), "results", matches)
@@ -89,9 +247,41 @@ func (s *Service) searchProviderWithCache(ctx context.Context, p Provider, query
 	// Save to Cache
 	ttl := p.CacheTTL()
 	if ttl == 0 {
-		ttl = 1 * time.Hour
+		ttl = defaultCacheTTL
+	}
+	if (len(matches) == 0 || slices.ContainsFunc(sources, SourceStatus.Partial)) && s.opts.NegativeTTL > 0 {
+		ttl = min(ttl, s.opts.NegativeTTL)
+	}
+	entry := s.newEntry(matches, ttl)
+	entry.Sources = sources
+	s.cache.Put(cacheKey, entry)
+
+	return entry, nil
+}
+
+// sourcesFor returns the per-provider outcome to report for entry. Providers that
+// answered when the entry was fetched are reported as cached if the entry was
+// served from the cache.
+func sourcesFor(entry CacheEntry, status CacheStatus) []SourceStatus {
+	if status == CacheMiss || len(entry.Sources) == 0 {
+		return entry.Sources
+	}
+	sources := slices.Clone(entry.Sources)
+	for i := range sources {
+		if sources[i].State == SourceOK {
+			sources[i].State = SourceCached
+		}
 	}
-	s.cache.Put(cacheKey, matches, ttl)
+	return sources
+}
 
-	return matches, nil
+// newEntry builds a cache entry whose stale window covers both stale-serving modes.
+func (s *Service) newEntry(data []AbsBookMetadata, ttl time.Duration) CacheEntry {
+	expiry := time.Now().Add(ttl)
+	stale := max(s.opts.StaleWhileRevalidate, s.opts.StaleIfError, 0)
+	return CacheEntry{
+		Data:       data,
+		Expiry:     expiry,
+		StaleUntil: expiry.Add(stale),
+	}
 }
@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -17,7 +20,7 @@ type MockProvider struct {
 
 func (m *MockProvider) ID() string { return m.IDVal }
 
-func (m *MockProvider) Search(_ context.Context, _ string) ([]AbsBookMetadata, error) {
+func (m *MockProvider) Search(_ context.Context, _ Query) ([]AbsBookMetadata, error) {
 	return m.SearchResults, m.SearchErr
 }
 
@@ -25,20 +28,38 @@ func (m *MockProvider) CacheTTL() time.Duration { return m.MockCacheTTL }
 
 // MockCache implements Cache for testing.
 type MockCache struct {
-	GetFunc func(key string) ([]AbsBookMetadata, bool)
-	PutFunc func(key string, data []AbsBookMetadata, ttl time.Duration)
+	GetFunc func(key string) (CacheEntry, bool)
+	PutFunc func(key string, entry CacheEntry)
 }
 
-func (m *MockCache) Get(key string) ([]AbsBookMetadata, bool) {
+func (m *MockCache) Get(key string) (CacheEntry, bool) {
 	if m.GetFunc != nil {
 		return m.GetFunc(key)
 	}
-	return nil, false
+	return CacheEntry{}, false
 }
 
-func (m *MockCache) Put(key string, data []AbsBookMetadata, ttl time.Duration) {
+func (m *MockCache) Put(key string, entry CacheEntry) {
 	if m.PutFunc != nil {
-		m.PutFunc(key, data, ttl)
+		m.PutFunc(key, entry)
+	}
+}
+
+// newMapCache returns a MockCache backed by a map, ignoring expiry.
+func newMapCache(store map[string]CacheEntry) *MockCache {
+	var mu sync.Mutex
+	return &MockCache{
+		GetFunc: func(key string) (CacheEntry, bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			e, ok := store[key]
+			return e, ok
+		},
+		PutFunc: func(key string, entry CacheEntry) {
+			mu.Lock()
+			defer mu.Unlock()
+			store[key] = entry
+		},
 	}
 }
 
@@ -53,21 +74,12 @@ func TestService_Search(t *testing.T) {
 		MockCacheTTL:  1 * time.Hour,
 	}
 
-	store := make(map[string][]AbsBookMetadata)
-	cache := &MockCache{
-		GetFunc: func(key string) ([]AbsBookMetadata, bool) {
-			d, ok := store[key]
-			return d, ok
-		},
-		PutFunc: func(key string, data []AbsBookMetadata, _ time.Duration) {
-			store[key] = data
-		},
-	}
+	cache := newMapCache(make(map[string]CacheEntry))
 
 	svc := NewService(cache, mockProvider)
 
 	// 1. Initial Search (should call provider "all")
-	resp, err := svc.Search(context.Background(), "RJ123456")
+	resp, err := svc.Search(context.Background(), Query{Text: "RJ123456"})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -83,7 +95,7 @@ func TestService_SearchByProviderID(t *testing.T) {
 	svc := NewService(&MockCache{}, p1, pAll)
 
 	t.Run("valid provider", func(t *testing.T) {
-		resp, err := svc.SearchByProviderID(context.Background(), "p1", "test")
+		resp, err := svc.SearchByProviderID(context.Background(), "p1", Query{Text: "test"})
 		if err != nil {
 			t.Fatalf("SearchByProviderID failed: %v", err)
 		}
@@ -93,7 +105,7 @@ func TestService_SearchByProviderID(t *testing.T) {
 	})
 
 	t.Run("all providers", func(t *testing.T) {
-		resp, err := svc.SearchByProviderID(context.Background(), "all", "test")
+		resp, err := svc.SearchByProviderID(context.Background(), "all", Query{Text: "test"})
 		if err != nil {
 			t.Fatalf("SearchByProviderID failed for 'all': %v", err)
 		}
@@ -103,7 +115,7 @@ func TestService_SearchByProviderID(t *testing.T) {
 	})
 
 	t.Run("unknown provider returns empty result (void)", func(t *testing.T) {
-		resp, err := svc.SearchByProviderID(context.Background(), "p3", "test")
+		resp, err := svc.SearchByProviderID(context.Background(), "p3", Query{Text: "test"})
 		if err != nil {
 			t.Fatalf("Expected no error for unknown provider, got %v", err)
 		}
@@ -115,7 +127,7 @@ func TestService_SearchByProviderID(t *testing.T) {
 	t.Run("nil matches returns empty slice", func(t *testing.T) {
 		pNil := &MockProvider{IDVal: "pNil", SearchResults: nil}
 		svcNil := NewService(&MockCache{}, pNil)
-		resp, err := svcNil.SearchByProviderID(context.Background(), "pNil", "test")
+		resp, err := svcNil.SearchByProviderID(context.Background(), "pNil", Query{Text: "test"})
 		if err != nil {
 			t.Fatalf("SearchByProviderID failed: %v", err)
 		}
@@ -137,7 +149,7 @@ func TestService_Search_PartialFailure(t *testing.T) {
 	cache := &MockCache{}
 	svc := NewService(cache, failingAll)
 
-	_, err := svc.Search(context.Background(), "test")
+	_, err := svc.Search(context.Background(), Query{Text: "test"})
 	if err == nil {
 		t.Error("expected error when delegated 'all' search fails")
 	}
@@ -149,22 +161,13 @@ func TestService_SearchProviderWithCache_ZeroTTL(t *testing.T) {
 		SearchResults: []AbsBookMetadata{{Title: "Cached"}},
 		MockCacheTTL:  0,
 	}
-	store := make(map[string][]AbsBookMetadata)
-	cache := &MockCache{
-		GetFunc: func(key string) ([]AbsBookMetadata, bool) {
-			d, ok := store[key]
-			return d, ok
-		},
-		PutFunc: func(key string, data []AbsBookMetadata, _ time.Duration) {
-			store[key] = data
-		},
-	}
+	cache := newMapCache(make(map[string]CacheEntry))
 	svc := NewService(cache, mock)
 
-	_, _ = svc.Search(context.Background(), "q")
+	_, _ = svc.Search(context.Background(), Query{Text: "q"})
 
 	mock.SearchErr = context.DeadlineExceeded
-	resp, err := svc.Search(context.Background(), "q")
+	resp, err := svc.Search(context.Background(), Query{Text: "q"})
 	if err != nil {
 		t.Fatalf("cached search failed: %v", err)
 	}
@@ -172,3 +175,503 @@ func TestService_SearchProviderWithCache_ZeroTTL(t *testing.T) {
 		t.Errorf("expected cached result, got %+v", resp.Matches)
 	}
 }
+
+// blockingProvider blocks in Search until release is closed and counts upstream calls.
+type blockingProvider struct {
+	id       string
+	calls    atomic.Int32
+	started  chan struct{}
+	release  chan struct{}
+	canceled chan struct{}
+}
+
+func newBlockingProvider(id string) *blockingProvider {
+	return &blockingProvider{
+		id:       id,
+		started:  make(chan struct{}, 16),
+		release:  make(chan struct{}),
+		canceled: make(chan struct{}, 16),
+	}
+}
+
+func (b *blockingProvider) ID() string              { return b.id }
+func (b *blockingProvider) CacheTTL() time.Duration { return time.Hour }
+func (b *blockingProvider) Search(ctx context.Context, _ Query) ([]AbsBookMetadata, error) {
+	b.calls.Add(1)
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+		return []AbsBookMetadata{{Title: "Shared"}}, nil
+	case <-ctx.Done():
+		b.canceled <- struct{}{}
+		return nil, ctx.Err()
+	}
+}
+
+func TestService_SearchProviderWithCache_CoalescesConcurrentRequests(t *testing.T) {
+	p := newBlockingProvider("dlsite")
+	svc := NewService(&MockCache{}, p)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "RJ123456"})
+			if err == nil && (len(resp.Matches) != 1 || resp.Matches[0].Title != "Shared") {
+				err = errors.New("unexpected matches")
+			}
+			errs <- err
+		}()
+	}
+
+	<-p.started
+	// Give the remaining callers time to join the in-flight fetch.
+	time.Sleep(50 * time.Millisecond)
+	close(p.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("caller failed: %v", err)
+		}
+	}
+	if got := p.calls.Load(); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+}
+
+func TestService_SearchProviderWithCache_CallerCancellation(t *testing.T) {
+	p := newBlockingProvider("dlsite")
+	svc := NewService(&MockCache{}, p)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	res1 := make(chan error, 1)
+	go func() {
+		_, err := svc.SearchByProviderID(ctx1, "dlsite", Query{Text: "q"})
+		res1 <- err
+	}()
+	<-p.started
+
+	res2 := make(chan error, 1)
+	go func() {
+		_, err := svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "q"})
+		res2 <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// The first caller leaving must not abort the fetch for the second one.
+	cancel1()
+	if err := <-res1; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled caller to get context.Canceled, got %v", err)
+	}
+	select {
+	case <-p.canceled:
+		t.Fatal("upstream fetch was canceled while another caller was waiting")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(p.release)
+	if err := <-res2; err != nil {
+		t.Errorf("expected remaining caller to succeed, got %v", err)
+	}
+}
+
+func TestService_SearchProviderWithCache_AllCallersCancel(t *testing.T) {
+	p := newBlockingProvider("dlsite")
+	svc := NewService(&MockCache{}, p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_, _ = svc.SearchByProviderID(ctx, "dlsite", Query{Text: "q"})
+		close(done)
+	}()
+	<-p.started
+	cancel()
+	<-done
+
+	select {
+	case <-p.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("expected upstream fetch to be canceled once all callers left")
+	}
+}
+
+func TestService_SearchProviderWithCache_StaleWhileRevalidate(t *testing.T) {
+	store := map[string]CacheEntry{
+		"dlsite:q": {
+			Data:       []AbsBookMetadata{{Title: "Old"}},
+			Expiry:     time.Now().Add(-1 * time.Minute),
+			StaleUntil: time.Now().Add(time.Hour),
+		},
+	}
+	cache := newMapCache(store)
+	p := newBlockingProvider("dlsite")
+	svc := NewService(cache, p)
+
+	resp, err := svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "q"})
+	if err != nil {
+		t.Fatalf("SearchByProviderID failed: %v", err)
+	}
+	if resp.CacheStatus != CacheStale {
+		t.Errorf("expected cache status %q, got %q", CacheStale, resp.CacheStatus)
+	}
+	if len(resp.Matches) != 1 || resp.Matches[0].Title != "Old" {
+		t.Errorf("expected stale data to be served immediately, got %+v", resp.Matches)
+	}
+
+	// The background refresh should replace the entry once the provider responds.
+	select {
+	case <-p.started:
+	case <-time.After(time.Second):
+		t.Fatal("expected a background refresh to start")
+	}
+	close(p.release)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if e, _ := cache.Get("dlsite:q"); len(e.Data) == 1 && e.Data[0].Title == "Shared" {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("expected background refresh to update the cache")
+}
+
+func TestService_SearchProviderWithCache_StaleIfError(t *testing.T) {
+	store := map[string]CacheEntry{
+		// Past the stale-while-revalidate window but within stale-if-error.
+		"dlsite:q": {
+			Data:       []AbsBookMetadata{{Title: "Old"}},
+			Expiry:     time.Now().Add(-2 * time.Hour),
+			StaleUntil: time.Now().Add(time.Hour),
+		},
+	}
+	p := &MockProvider{IDVal: "dlsite", SearchErr: errors.New("upstream 503")}
+	svc := NewService(newMapCache(store), p)
+
+	resp, err := svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "q"})
+	if err != nil {
+		t.Fatalf("expected stale fallback instead of error, got %v", err)
+	}
+	if resp.CacheStatus != CacheStale {
+		t.Errorf("expected cache status %q, got %q", CacheStale, resp.CacheStatus)
+	}
+	if len(resp.Matches) != 1 || resp.Matches[0].Title != "Old" {
+		t.Errorf("expected stale data, got %+v", resp.Matches)
+	}
+}
+
+func TestService_SearchProviderWithCache_StaleDisabled(t *testing.T) {
+	store := map[string]CacheEntry{
+		"dlsite:q": {
+			Data:       []AbsBookMetadata{{Title: "Old"}},
+			Expiry:     time.Now().Add(-1 * time.Minute),
+			StaleUntil: time.Now().Add(time.Hour),
+		},
+	}
+	p := &MockProvider{IDVal: "dlsite", SearchErr: errors.New("upstream 503")}
+	svc := NewServiceWithOptions(newMapCache(store), Options{StaleWhileRevalidate: -1, StaleIfError: -1}, p)
+
+	if _, err := svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "q"}); err == nil {
+		t.Error("expected provider error when stale serving is disabled")
+	}
+}
+
+func TestService_SearchProviderWithCache_EntryWindow(t *testing.T) {
+	store := make(map[string]CacheEntry)
+	p := &MockProvider{IDVal: "dlsite", SearchResults: []AbsBookMetadata{{Title: "New"}}, MockCacheTTL: time.Hour}
+	svc := NewServiceWithOptions(newMapCache(store), Options{StaleWhileRevalidate: time.Minute, StaleIfError: 2 * time.Hour}, p)
+
+	resp, err := svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "q"})
+	if err != nil {
+		t.Fatalf("SearchByProviderID failed: %v", err)
+	}
+	if resp.CacheStatus != CacheMiss {
+		t.Errorf("expected cache status %q, got %q", CacheMiss, resp.CacheStatus)
+	}
+
+	e := store["dlsite:q"]
+	if got := e.StaleUntil.Sub(e.Expiry); got != 2*time.Hour {
+		t.Errorf("expected stale window of 2h, got %v", got)
+	}
+}
+
+func TestService_SearchProviderWithCache_NegativeCaching(t *testing.T) {
+	notFound := fmt.Errorf("work gone: %w", ErrNotFound)
+
+	tests := []struct {
+		name    string
+		results []AbsBookMetadata
+		err     error
+	}{
+		{"not found error", nil, notFound},
+		{"empty result", []AbsBookMetadata{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := make(map[string]CacheEntry)
+			p := &MockProvider{IDVal: "dlsite", SearchResults: tt.results, SearchErr: tt.err, MockCacheTTL: 24 * time.Hour}
+			svc := NewServiceWithOptions(newMapCache(store), Options{NegativeTTL: 5 * time.Minute}, p)
+
+			resp, err := svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "RJ000000"})
+			if err != nil {
+				t.Fatalf("expected not-found to yield an empty result, got %v", err)
+			}
+			if len(resp.Matches) != 0 {
+				t.Errorf("expected 0 matches, got %d", len(resp.Matches))
+			}
+
+			e, ok := store["dlsite:RJ000000"]
+			if !ok {
+				t.Fatal("expected negative result to be cached")
+			}
+			if ttl := time.Until(e.Expiry); ttl > 5*time.Minute || ttl < 4*time.Minute {
+				t.Errorf("expected negative TTL of ~5m, got %v", ttl)
+			}
+		})
+	}
+}
+
+func TestService_SearchProviderWithCache_ErrorsNotCached(t *testing.T) {
+	store := make(map[string]CacheEntry)
+	p := &MockProvider{IDVal: "dlsite", SearchErr: errors.New("upstream 500")}
+	svc := NewService(newMapCache(store), p)
+
+	if _, err := svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "q"}); err == nil {
+		t.Fatal("expected error to be propagated")
+	}
+	if len(store) != 0 {
+		t.Errorf("expected transient errors not to be cached, got %d entries", len(store))
+	}
+}
+
+// reportingProvider is a MockProvider that reports per-source outcomes.
+type reportingProvider struct {
+	MockProvider
+	sources []SourceStatus
+}
+
+func (r *reportingProvider) SearchWithSources(ctx context.Context, query Query) ([]AbsBookMetadata, []SourceStatus, error) {
+	matches, err := r.Search(ctx, query)
+	return matches, r.sources, err
+}
+
+func TestService_SearchByProviderID_Sources(t *testing.T) {
+	store := make(map[string]CacheEntry)
+	p := &reportingProvider{
+		MockProvider: MockProvider{IDVal: "all", SearchResults: []AbsBookMetadata{{Title: "Partial"}}, MockCacheTTL: 24 * time.Hour},
+		sources: []SourceStatus{
+			{Provider: "dlsite", State: SourceOK, Count: 1},
+			{Provider: "other", State: SourceTimeout},
+		},
+	}
+	svc := NewServiceWithOptions(newMapCache(store), Options{NegativeTTL: 5 * time.Minute}, p)
+
+	resp, err := svc.SearchByProviderID(context.Background(), "all", Query{Text: "q"})
+	if err != nil {
+		t.Fatalf("SearchByProviderID failed: %v", err)
+	}
+	if len(resp.Sources) != 2 || resp.Sources[0].State != SourceOK || resp.Sources[1].State != SourceTimeout {
+		t.Errorf("expected the provider's sources, got %+v", resp.Sources)
+	}
+	if ttl := time.Until(store["all:q"].Expiry); ttl > 5*time.Minute {
+		t.Errorf("expected partial results to be cached for the negative TTL, got %v", ttl)
+	}
+
+	resp, err = svc.SearchByProviderID(context.Background(), "all", Query{Text: "q"})
+	if err != nil {
+		t.Fatalf("SearchByProviderID failed: %v", err)
+	}
+	if resp.CacheStatus != CacheHit || resp.Sources[0].State != SourceCached || resp.Sources[1].State != SourceTimeout {
+		t.Errorf("expected answering sources to be reported as cached, got %s %+v", resp.CacheStatus, resp.Sources)
+	}
+	if store["all:q"].Sources[0].State != SourceOK {
+		t.Error("expected the cached sources not to be modified")
+	}
+}
+
+// describedProvider is a MockProvider that describes its capabilities.
+type describedProvider struct {
+	MockProvider
+	info ProviderInfo
+}
+
+func (d *describedProvider) Describe() ProviderInfo { return d.info }
+
+func TestService_ProviderInfos(t *testing.T) {
+	described := &describedProvider{
+		MockProvider: MockProvider{IDVal: "dlsite", MockCacheTTL: 24 * time.Hour, SearchErr: errors.New("upstream down")},
+		info: ProviderInfo{
+			ID:           "ignored",
+			Name:         "DLsite",
+			QueryKinds:   []QueryKind{QueryKindID, QueryKindKeyword},
+			AdultContent: true,
+		},
+	}
+	plain := &MockProvider{IDVal: "plain", MockCacheTTL: time.Hour}
+	svc := NewService(&MockCache{}, described, plain)
+
+	infos := svc.ProviderInfos()
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(infos))
+	}
+
+	got := infos[0]
+	if got.ID != "dlsite" || got.Name != "DLsite" || !got.AdultContent || len(got.QueryKinds) != 2 {
+		t.Errorf("unexpected info for described provider: %+v", got)
+	}
+	if got.CacheTTLSeconds != 86400 || !got.Healthy {
+		t.Errorf("unexpected TTL/health for described provider: %+v", got)
+	}
+	if infos[1].Name != "plain" || infos[1].QueryKinds == nil {
+		t.Errorf("expected defaults for undescribed provider, got %+v", infos[1])
+	}
+
+	for i := 0; i < unhealthyAfterFailures; i++ {
+		_, _ = svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: fmt.Sprintf("q%d", i)})
+	}
+	if svc.ProviderInfos()[0].Healthy {
+		t.Error("expected provider to be unhealthy after consecutive failures")
+	}
+
+	described.SearchErr = nil
+	_, _ = svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "ok"})
+	if !svc.ProviderInfos()[0].Healthy {
+		t.Error("expected provider to recover after a successful fetch")
+	}
+}
+
+// circuitProvider is a MockProvider guarded by a circuit breaker in the given state.
+type circuitProvider struct {
+	MockProvider
+	state CircuitState
+}
+
+func (c *circuitProvider) CircuitState() CircuitState { return c.state }
+
+func TestService_Health(t *testing.T) {
+	guarded := &circuitProvider{MockProvider: MockProvider{IDVal: "dlsite"}, state: CircuitClosed}
+	svc := NewService(newMapCache(make(map[string]CacheEntry)), guarded, &MockProvider{IDVal: "void"})
+
+	report := svc.Health()
+	if report.Status != HealthOK || len(report.Providers) != 2 {
+		t.Fatalf("expected a healthy report for 2 providers, got %+v", report)
+	}
+	if report.Providers[0].Circuit != CircuitClosed || report.Providers[1].Circuit != "" {
+		t.Errorf("expected the circuit state of guarded providers only, got %+v", report.Providers)
+	}
+
+	guarded.state = CircuitOpen
+	report = svc.Health()
+	if report.Status != HealthDegraded || report.Providers[0].Healthy || report.Providers[0].Circuit != CircuitOpen {
+		t.Errorf("expected an open circuit to degrade the report, got %+v", report)
+	}
+	if svc.ProviderInfos()[0].Healthy {
+		t.Error("expected a provider with an open circuit to be listed as unhealthy")
+	}
+}
+
+// parsingProvider is a MockProvider that scrapes pages with a parser in the given state.
+type parsingProvider struct {
+	MockProvider
+	stats ParserStats
+}
+
+func (p *parsingProvider) ParserStats() ParserStats { return p.stats }
+
+func TestService_Health_ParserDegraded(t *testing.T) {
+	scraper := &parsingProvider{MockProvider: MockProvider{IDVal: "dlsite"}, stats: ParserStats{State: ParserOK, Validated: 4}}
+	unparsed := &parsingProvider{MockProvider: MockProvider{IDVal: "void"}}
+	svc := NewService(newMapCache(make(map[string]CacheEntry)), scraper, unparsed)
+
+	report := svc.Health()
+	if report.Status != HealthOK || report.Providers[0].Parser != ParserOK || report.Providers[1].Parser != "" {
+		t.Fatalf("expected a healthy report with the scraper's parser state, got %+v", report)
+	}
+	if stats := svc.ParserStats(); len(stats) != 1 || stats["dlsite"].Validated != 4 {
+		t.Errorf("expected the parser stats of the scraper only, got %+v", stats)
+	}
+
+	scraper.stats.State = ParserDegraded
+	report = svc.Health()
+	if report.Status != HealthDegraded || !report.Providers[0].Healthy || report.Providers[0].Parser != ParserDegraded {
+		t.Errorf("expected a degraded parser to degrade the report only, got %+v", report)
+	}
+}
+
+func TestService_SearchByProviderID_CircuitOpenServesStale(t *testing.T) {
+	store := map[string]CacheEntry{
+		"dlsite:q": {Data: []AbsBookMetadata{{Title: "Stale"}}, Expiry: time.Now().Add(-2 * time.Hour), StaleUntil: time.Now().Add(time.Hour)},
+	}
+	p := &MockProvider{IDVal: "dlsite", SearchErr: fmt.Errorf("dlsite: %w", ErrCircuitOpen)}
+	svc := NewServiceWithOptions(newMapCache(store), Options{StaleWhileRevalidate: time.Minute, StaleIfError: 24 * time.Hour}, p)
+
+	resp, err := svc.SearchByProviderID(context.Background(), "dlsite", Query{Text: "q"})
+	if err != nil {
+		t.Fatalf("expected the stale entry to be served, got %v", err)
+	}
+	if resp.CacheStatus != CacheStale || resp.Matches[0].Title != "Stale" {
+		t.Errorf("expected the stale entry, got %s %+v", resp.CacheStatus, resp.Matches)
+	}
+}
+
+func TestQuery_CacheKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{"text only", Query{Text: "RJ123456"}, "RJ123456"},
+		{"with author", Query{Text: "foo", Author: "bar"}, "?author=bar&q=foo"},
+		{"title and author", Query{Title: "foo bar", Author: "baz"}, "?author=baz&title=foo+bar"},
+		{"with language", Query{Text: "RJ123456", Language: "en"}, "?lang=en&q=RJ123456"},
+		{"with paging", Query{Text: "foo", Limit: 20, Page: 2}, "?limit=20&page=2&q=foo"},
+		{"first page", Query{Text: "foo", Page: 1}, "foo"},
+		{"text like a key", Query{Text: "?q=foo"}, "?q=%3Fq%3Dfoo"},
+		{"with filters", Query{Filters: Filters{Age: AgeAll, VoiceActor: "cv"}}, "?age=all&cv=cv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.CacheKey(); got != tt.want {
+				t.Errorf("CacheKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if (Query{Text: "foo"}).CacheKey() == (Query{Text: "foo", Author: "bar"}).CacheKey() {
+		t.Error("queries with different authors must not share a cache key")
+	}
+	for _, text := range []string{"q=foo&title=bar", "?q=foo&title=bar"} {
+		if (Query{Text: text}).CacheKey() == (Query{Text: "foo", Title: "bar"}).CacheKey() {
+			t.Errorf("text %q must not share a cache key with a structured query", text)
+		}
+	}
+}
+
+func TestService_SearchByProviderID_UnsupportedFilter(t *testing.T) {
+	filtered := &describedProvider{
+		MockProvider: MockProvider{IDVal: "filtered", SearchResults: []AbsBookMetadata{{Title: "Match"}}},
+		info:         ProviderInfo{Filters: []Filter{FilterAge, FilterMaker}},
+	}
+	plain := &MockProvider{IDVal: "plain"}
+	svc := NewService(&MockCache{}, filtered, plain)
+
+	query := Query{Text: "q", Filters: Filters{Age: AgeR18, Maker: "circle"}}
+	if _, err := svc.SearchByProviderID(context.Background(), "filtered", query); err != nil {
+		t.Errorf("expected supported filters to be accepted, got %v", err)
+	}
+
+	query.Filters.VoiceActor = "cv"
+	if _, err := svc.SearchByProviderID(context.Background(), "filtered", query); !errors.Is(err, ErrUnsupportedFilter) {
+		t.Errorf("expected ErrUnsupportedFilter, got %v", err)
+	}
+	if got := UnsupportedFilters(plain, query.Filters); len(got) != 3 || got[0] != FilterAge {
+		t.Errorf("expected every filter to be unsupported by an undescribed provider, got %v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Query is a structured search request. Audiobookshelf sends a free-text query
+// together with the title and author of the item being matched.
+type Query struct {
+	// Text is the free-text query ("q" or "query" parameter).
+	Text string
+	// Title is the title of the item being matched, if known.
+	Title string
+	// Author is the author of the item being matched, if known.
+	Author string
+	// Language is the preferred language of the result (e.g. "en" or "English").
+	// Providers that know several editions of a work return the one in this language.
+	Language string
+
+	// Limit is the maximum number of results per page (0 = provider default).
+	Limit int
+	// Page is the 1-based page of results to return (0 = first page).
+	Page int
+
+	// Filters narrow the search. Providers declare which filters they support.
+	Filters Filters
+}
+
+// Filters narrow a search. Empty fields are not applied.
+type Filters struct {
+	// Age is the age category: AgeAll, AgeR15 or AgeR18.
+	Age string
+	// VoiceActor is the name of a voice actor appearing in the work.
+	VoiceActor string
+	// Maker is the name of the circle, brand or publisher.
+	Maker string
+	// Genre is a provider-specific genre ID or name.
+	Genre string
+	// ReleasedAfter and ReleasedBefore bound the release date (YYYY-MM-DD, inclusive).
+	ReleasedAfter  string
+	ReleasedBefore string
+}
+
+// Age categories accepted by Filters.Age.
+const (
+	AgeAll = "all"
+	AgeR15 = "r15"
+	AgeR18 = "r18"
+)
+
+// Values returns the applied filters keyed by their query parameter name.
+func (f Filters) Values() url.Values {
+	v := url.Values{}
+	for name, value := range map[Filter]string{
+		FilterAge:            f.Age,
+		FilterVoiceActor:     f.VoiceActor,
+		FilterMaker:          f.Maker,
+		FilterGenre:          f.Genre,
+		FilterReleasedAfter:  f.ReleasedAfter,
+		FilterReleasedBefore: f.ReleasedBefore,
+	} {
+		if value != "" {
+			v.Set(string(name), value)
+		}
+	}
+	return v
+}
+
+// IsZero reports whether no filter is applied.
+func (f Filters) IsZero() bool {
+	return f == Filters{}
+}
+
+// MaxSearchLimit caps Query.Limit, since providers fetch details for every result.
+// Providers may return results past what their upstream rate limit lets them
+// enrich in time with partial metadata.
+const MaxSearchLimit = 50
+
+// LimitOr returns the query's limit, or def when none was requested.
+func (q Query) LimitOr(def int) int {
+	if q.Limit <= 0 {
+		return def
+	}
+	return min(q.Limit, MaxSearchLimit)
+}
+
+// PageNumber returns the 1-based page requested by the query.
+func (q Query) PageNumber() int {
+	return max(q.Page, 1)
+}
+
+// Term returns the text providers should search for: Text, or Title when Text is empty.
+func (q Query) Term() string {
+	if q.Text != "" {
+		return q.Text
+	}
+	return q.Title
+}
+
+// IsZero reports whether the query has nothing to search for.
+// A query without search text may still list the works matching its filters.
+func (q Query) IsZero() bool {
+	return q.Term() == "" && q.Filters.IsZero()
+}
+
+// CacheKey returns a stable string identifying the query.
+// A query consisting only of free text is keyed by the text itself; any other query
+// is keyed by its encoded parameters after a "?", which the text form never starts
+// with, so that no text can pass for a structured query.
+func (q Query) CacheKey() string {
+	if q.Page == 1 {
+		q.Page = 0 // the first page is the default
+	}
+	if q == (Query{Text: q.Text}) && !strings.HasPrefix(q.Text, "?") {
+		return q.Text
+	}
+
+	v := q.Filters.Values()
+	if q.Text != "" {
+		v.Set("q", q.Text)
+	}
+	if q.Title != "" {
+		v.Set("title", q.Title)
+	}
+	if q.Author != "" {
+		v.Set("author", q.Author)
+	}
+	if q.Language != "" {
+		v.Set("lang", q.Language)
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Page > 0 {
+		v.Set("page", strconv.Itoa(q.Page))
+	}
+	// Encode sorts by key, so the result is deterministic.
+	return "?" + v.Encode()
+}
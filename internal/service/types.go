@@ -2,9 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"time"
 )
 
+var (
+	// ErrNotFound is reported (usually wrapped) by providers when the requested work does not exist upstream.
+	ErrNotFound = errors.New("not found")
+	// ErrWorkLookupUnsupported is returned when a provider cannot look up works by ID.
+	ErrWorkLookupUnsupported = errors.New("provider does not support work lookup")
+	// ErrUnsupportedFilter is returned when a search uses filters the provider does not support.
+	ErrUnsupportedFilter = errors.New("provider does not support filter")
+	// ErrCircuitOpen is reported (usually wrapped) by providers whose circuit breaker is
+	// open, i.e. that fail fast instead of contacting an upstream that keeps failing.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+	// ErrAccessDenied is reported (usually wrapped) by providers whose upstream refuses to
+	// serve the content, e.g. behind an age gate or a login wall. It is a setup problem
+	// rather than an outage, so retrying does not help.
+	ErrAccessDenied = errors.New("access denied by upstream")
+)
+
 // SeriesMetadata represents series information for a book.
 type SeriesMetadata struct {
 	Series   string `json:"series"`
@@ -26,11 +44,85 @@ type AbsBookMetadata struct {
 	ISBN          string           `json:"isbn,omitempty"`
 	Language      string           `json:"language,omitempty"`
 	Explicit      bool             `json:"explicit,omitempty"`
+
+	// Score is the relevance of the match to the query, set when results are ranked.
+	// It is a debugging aid and only included in responses on request.
+	Score float64 `json:"score,omitempty"`
+}
+
+// WorkDetail is a fully-populated record for a single work. It extends the
+// Audiobookshelf metadata with provider fields that AbsBookMetadata does not carry.
+type WorkDetail struct {
+	AbsBookMetadata
+	ID          string   `json:"id"`
+	Provider    string   `json:"provider"`
+	URL         string   `json:"url,omitempty"`
+	Circle      string   `json:"circle,omitempty"`
+	Scenario    string   `json:"scenario,omitempty"`
+	VoiceActors []string `json:"voiceActors,omitempty"`
+	ReleaseDate string   `json:"releaseDate,omitempty"`
+	Price       int      `json:"price,omitempty"`
+	AgeRating   string   `json:"ageRating,omitempty"`
+	WorkFormat  string   `json:"workFormat,omitempty"`
+	Languages   []string `json:"languages,omitempty"`
+	// OriginalID is the ID of the work this one was translated from, if it is a translation.
+	OriginalID string `json:"originalId,omitempty"`
+	// Editions lists the other language editions of the work, including the original.
+	Editions []Edition `json:"editions,omitempty"`
+}
+
+// Edition is another language edition of a work, e.g. an official translation.
+type Edition struct {
+	ID       string `json:"id"`
+	Language string `json:"language,omitempty"`
 }
 
 // AbsMetadataResponse represents the search response format for Audiobookshelf.
 type AbsMetadataResponse struct {
 	Matches []AbsBookMetadata `json:"matches"`
+
+	// CacheStatus reports how the matches were obtained (hit, miss or stale).
+	// It is surfaced as a response header rather than in the body.
+	CacheStatus CacheStatus `json:"-"`
+
+	// Sources reports how each provider of an aggregated search fared.
+	// It is a debugging aid and only included in responses on request.
+	Sources []SourceStatus `json:"sources,omitempty"`
+}
+
+// CacheStatus describes whether a result came from the cache.
+type CacheStatus string
+
+const (
+	// CacheHit means the result was served from a fresh cache entry.
+	CacheHit CacheStatus = "HIT"
+	// CacheMiss means the result was fetched from the provider.
+	CacheMiss CacheStatus = "MISS"
+	// CacheStale means an expired entry was served, either while it is being
+	// refreshed in the background or because the provider failed.
+	CacheStale CacheStatus = "STALE"
+)
+
+// CacheEntry is a cached search result together with its freshness window.
+type CacheEntry struct {
+	Data []AbsBookMetadata
+	// Expiry is the time at which the entry stops being fresh.
+	Expiry time.Time
+	// StaleUntil is the time after which the entry must not be served at all.
+	// Caches retain entries until this time.
+	StaleUntil time.Time
+	// Sources is the per-provider outcome of an aggregated search, if any.
+	Sources []SourceStatus
+}
+
+// Fresh reports whether the entry is still within its TTL at the given time.
+func (e CacheEntry) Fresh(now time.Time) bool {
+	return now.Before(e.Expiry)
+}
+
+// Usable reports whether the entry may still be served, fresh or stale.
+func (e CacheEntry) Usable(now time.Time) bool {
+	return now.Before(e.Expiry) || now.Before(e.StaleUntil)
 }
 
 // Provider defines the interface for all metadata provider plugins.
@@ -39,8 +131,173 @@ type Provider interface {
 	ID() string
 
 	// Search searches for works matching the query and returns ABS-compatible metadata.
-	Search(ctx context.Context, query string) ([]AbsBookMetadata, error)
+	Search(ctx context.Context, query Query) ([]AbsBookMetadata, error)
 
 	// CacheTTL returns the duration for which results should be cached.
 	CacheTTL() time.Duration
 }
+
+// WorkFetcher is implemented by providers that can look up a single work by its ID.
+type WorkFetcher interface {
+	// GetWork returns the work with the given ID, or an error wrapping ErrNotFound.
+	GetWork(ctx context.Context, id string) (*WorkDetail, error)
+}
+
+// QueryKind identifies a kind of lookup a provider supports.
+type QueryKind string
+
+const (
+	// QueryKindID is a lookup by product code (e.g. an RJ code).
+	QueryKindID QueryKind = "id"
+	// QueryKindKeyword is a free-text search.
+	QueryKindKeyword QueryKind = "keyword"
+)
+
+// Filter identifies a search filter by its query parameter name.
+type Filter string
+
+// Filters and their query parameters; see the corresponding Filters fields.
+const (
+	FilterAge            Filter = "age"
+	FilterVoiceActor     Filter = "cv"
+	FilterMaker          Filter = "maker"
+	FilterGenre          Filter = "genre"
+	FilterReleasedAfter  Filter = "released_after"
+	FilterReleasedBefore Filter = "released_before"
+)
+
+// ProviderInfo describes a provider's identity and capabilities.
+type ProviderInfo struct {
+	ID              string      `json:"id"`
+	Name            string      `json:"name"`
+	QueryKinds      []QueryKind `json:"queryKinds"`
+	Filters         []Filter    `json:"filters"`
+	CacheTTLSeconds int64       `json:"cacheTtlSeconds"`
+	Healthy         bool        `json:"healthy"`
+	AdultContent    bool        `json:"adultContent"`
+}
+
+// Describer is implemented by providers that can describe their capabilities.
+// ID, CacheTTLSeconds and Healthy are filled in by the service.
+type Describer interface {
+	Describe() ProviderInfo
+}
+
+// SourceState is the outcome of one provider's part in an aggregated search.
+type SourceState string
+
+const (
+	// SourceOK means the provider answered in time.
+	SourceOK SourceState = "ok"
+	// SourceCached means the provider's results were served from a cached aggregate.
+	SourceCached SourceState = "cached"
+	// SourceFailed means the provider returned an error; its results are missing.
+	SourceFailed SourceState = "failed"
+	// SourceTimeout means the provider did not answer in time; its results are missing.
+	SourceTimeout SourceState = "timeout"
+	// SourceSkipped means the provider was not asked, e.g. because it does not
+	// support the query's filters.
+	SourceSkipped SourceState = "skipped"
+)
+
+// SourceStatus reports how one provider fared in an aggregated search.
+type SourceStatus struct {
+	Provider string      `json:"provider"`
+	State    SourceState `json:"state"`
+	Count    int         `json:"count"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// Partial reports whether the provider's results are missing because it failed.
+func (s SourceStatus) Partial() bool {
+	return s.State == SourceFailed || s.State == SourceTimeout
+}
+
+// SourceReporter is implemented by providers that aggregate other providers and
+// can report how each of them fared.
+type SourceReporter interface {
+	// SearchWithSources searches like Provider.Search and additionally returns one
+	// status per aggregated provider.
+	SearchWithSources(ctx context.Context, query Query) ([]AbsBookMetadata, []SourceStatus, error)
+}
+
+// ProductIDFinder is implemented by providers whose works have product IDs that can
+// be recognised in free text, such as DLsite's RJ codes. The IDs are the ones the
+// provider reports in AbsBookMetadata.ISBN.
+type ProductIDFinder interface {
+	// FindProductID returns the first product ID embedded in s, in canonical form.
+	FindProductID(s string) (string, bool)
+}
+
+// CircuitState is the state of a provider's circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitClosed means requests go upstream as usual.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen means requests fail fast after repeated upstream failures.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen means a probe request is testing whether the upstream recovered.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitReporter is implemented by providers guarded by a circuit breaker.
+type CircuitReporter interface {
+	CircuitState() CircuitState
+}
+
+// ParserState is the state of a scraping provider's page parser.
+type ParserState string
+
+const (
+	// ParserOK means the pages parsed recently had every mandatory field.
+	ParserOK ParserState = "ok"
+	// ParserDegraded means several pages in a row lacked mandatory fields, most
+	// likely because the upstream markup changed.
+	ParserDegraded ParserState = "degraded"
+)
+
+// ParserStats are the validation counters of a scraping provider's page parser.
+type ParserStats struct {
+	State ParserState `json:"state"`
+	// Validated is the number of parsed pages that were validated.
+	Validated int64 `json:"validated"`
+	// Invalid is the number of pages that lacked a mandatory field.
+	Invalid int64 `json:"invalid"`
+	// MissingFields counts how often each mandatory field was missing.
+	MissingFields map[string]int64 `json:"missingFields,omitempty"`
+	// UnknownHeaders counts the headers of the page's data table the parser did not recognise.
+	UnknownHeaders map[string]int64 `json:"unknownHeaders,omitempty"`
+	// LastInvalid is the ID of the last work whose page failed validation.
+	LastInvalid string `json:"lastInvalid,omitempty"`
+}
+
+// ParserReporter is implemented by providers that validate the pages they scrape.
+// An empty State means the provider does not parse pages.
+type ParserReporter interface {
+	ParserStats() ParserStats
+}
+
+// UnsupportedFilters returns the filters applied by f that p does not declare
+// in its ProviderInfo. Providers that do not describe themselves support no filters.
+func UnsupportedFilters(p Provider, f Filters) []Filter {
+	if f.IsZero() {
+		return nil
+	}
+
+	supported := make(map[Filter]bool)
+	if d, ok := p.(Describer); ok {
+		for _, filter := range d.Describe().Filters {
+			supported[filter] = true
+		}
+	}
+
+	var unsupported []Filter
+	for name := range f.Values() {
+		if !supported[Filter(name)] {
+			unsupported = append(unsupported, Filter(name))
+		}
+	}
+	slices.Sort(unsupported)
+	return unsupported
+}
@@ -23,7 +23,7 @@ func (m *MockProvider) ID() string {
 	return m.IDVal
 }
 
-func (m *MockProvider) Search(_ context.Context, _ string) ([]service.AbsBookMetadata, error) {
+func (m *MockProvider) Search(_ context.Context, _ service.Query) ([]service.AbsBookMetadata, error) {
 	return m.SearchResults, m.SearchErr
 }
 
@@ -34,8 +34,10 @@ func (m *MockProvider) CacheTTL() time.Duration {
 // integrationCache implements service.Cache for integration tests.
 type integrationCache struct{}
 
-func (i *integrationCache) Get(_ string) ([]service.AbsBookMetadata, bool)             { return nil, false }
-func (i *integrationCache) Put(_ string, _ []service.AbsBookMetadata, _ time.Duration) {}
+func (i *integrationCache) Get(_ string) (service.CacheEntry, bool) {
+	return service.CacheEntry{}, false
+}
+func (i *integrationCache) Put(_ string, _ service.CacheEntry) {}
 
 func TestAPI_Search_Integration(t *testing.T) {
 	mockData := []service.AbsBookMetadata{{Title: "Integration Test Title", ISBN: "RJ123456"}}